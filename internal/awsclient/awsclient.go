@@ -0,0 +1,170 @@
+// Package awsclient builds aws.Config values with a tunable HTTP client and
+// retry policy, so a service that makes both long-poll calls (SQS) and
+// short request/response calls (CloudWatch, DynamoDB) doesn't have to share
+// one set of timeouts between them. See ConfigFromEnv for the environment
+// variables that override the defaults.
+package awsclient
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+)
+
+// Retry mode identifiers for Config.RetryMode.
+const (
+	RetryModeStandard = "standard"
+	RetryModeAdaptive = "adaptive"
+)
+
+const (
+	defaultTimeout             = 10 * time.Second
+	defaultMaxIdleConns        = 100
+	defaultMaxIdleConnsPerHost = 10
+	defaultIdleConnTimeout     = 90 * time.Second
+)
+
+// Config tunes the HTTP client and retry policy built for one AWS service
+// client. The zero value is not usable directly; build one with
+// ConfigFromEnv or set every field explicitly.
+type Config struct {
+	// Timeout bounds a single HTTP attempt; each retry the SDK makes gets
+	// its own Timeout. SQS long-polling needs this comfortably above its
+	// WaitTimeSeconds (up to 20s); CloudWatch and DynamoDB calls don't.
+	Timeout time.Duration
+
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	IdleConnTimeout     time.Duration
+
+	// MaxRetries caps attempts for a single request (the first attempt plus
+	// retries). Zero falls back to retry.DefaultMaxAttempts.
+	MaxRetries int
+	// RetryMode selects retry.NewStandard or retry.NewAdaptive. Defaults to
+	// RetryModeStandard; RetryModeAdaptive additionally throttles the
+	// client's own request rate in response to repeated throttling errors.
+	RetryMode string
+}
+
+func (c *Config) setDefaults() {
+	if c.Timeout <= 0 {
+		c.Timeout = defaultTimeout
+	}
+	if c.MaxIdleConns <= 0 {
+		c.MaxIdleConns = defaultMaxIdleConns
+	}
+	if c.MaxIdleConnsPerHost <= 0 {
+		c.MaxIdleConnsPerHost = defaultMaxIdleConnsPerHost
+	}
+	if c.IdleConnTimeout <= 0 {
+		c.IdleConnTimeout = defaultIdleConnTimeout
+	}
+	if c.MaxRetries <= 0 {
+		c.MaxRetries = retry.DefaultMaxAttempts
+	}
+	if c.RetryMode == "" {
+		c.RetryMode = RetryModeStandard
+	}
+}
+
+// ConfigFromEnv builds a Config for a service identified by envPrefix (e.g.
+// "SQS", "CW"), reading overrides from environment variables:
+//
+//   - <envPrefix>_HTTP_TIMEOUT (time.ParseDuration syntax, e.g. "25s"):
+//     Config.Timeout, defaulting to defaultTimeout.
+//   - AWS_MAX_IDLE_CONNS, AWS_MAX_IDLE_CONNS_PER_HOST, AWS_IDLE_CONN_TIMEOUT:
+//     shared across services since they describe the process's connection
+//     pool rather than any one service's call pattern.
+//   - AWS_MAX_RETRIES, AWS_RETRY_MODE ("standard" or "adaptive"): also
+//     shared.
+//
+// Any variable that's unset or fails to parse is left at its default.
+func ConfigFromEnv(envPrefix string) Config {
+	cfg := Config{
+		Timeout:             envDuration(envPrefix+"_HTTP_TIMEOUT", defaultTimeout),
+		MaxIdleConns:        envInt("AWS_MAX_IDLE_CONNS", defaultMaxIdleConns),
+		MaxIdleConnsPerHost: envInt("AWS_MAX_IDLE_CONNS_PER_HOST", defaultMaxIdleConnsPerHost),
+		IdleConnTimeout:     envDuration("AWS_IDLE_CONN_TIMEOUT", defaultIdleConnTimeout),
+		MaxRetries:          envInt("AWS_MAX_RETRIES", retry.DefaultMaxAttempts),
+		RetryMode:           envString("AWS_RETRY_MODE", RetryModeStandard),
+	}
+	cfg.setDefaults()
+	return cfg
+}
+
+// LoadConfig loads an aws.Config the same way config.LoadDefaultConfig does
+// (shared credentials, region, etc. from the environment), but with its
+// HTTPClient and Retryer built from cfg instead of the SDK's defaults.
+func LoadConfig(ctx context.Context, cfg Config) (aws.Config, error) {
+	cfg.setDefaults()
+	return awsconfig.LoadDefaultConfig(ctx,
+		awsconfig.WithHTTPClient(newHTTPClient(cfg)),
+		awsconfig.WithRetryer(newRetryer(cfg)),
+	)
+}
+
+// newHTTPClient builds an *http.Client tuned per cfg.
+func newHTTPClient(cfg Config) *http.Client {
+	return &http.Client{
+		Timeout: cfg.Timeout,
+		Transport: &http.Transport{
+			MaxIdleConns:        cfg.MaxIdleConns,
+			MaxIdleConnsPerHost: cfg.MaxIdleConnsPerHost,
+			IdleConnTimeout:     cfg.IdleConnTimeout,
+		},
+	}
+}
+
+// newRetryer returns a constructor suitable for config.WithRetryer, building
+// an aws.Retryer per cfg.RetryMode and cfg.MaxRetries.
+func newRetryer(cfg Config) func() aws.Retryer {
+	return func() aws.Retryer {
+		if cfg.RetryMode == RetryModeAdaptive {
+			return retry.NewAdaptiveMode(func(o *retry.AdaptiveModeOptions) {
+				o.StandardOptions = append(o.StandardOptions, func(so *retry.StandardOptions) {
+					so.MaxAttempts = cfg.MaxRetries
+				})
+			})
+		}
+		return retry.NewStandard(func(o *retry.StandardOptions) {
+			o.MaxAttempts = cfg.MaxRetries
+		})
+	}
+}
+
+func envDuration(key string, def time.Duration) time.Duration {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return def
+	}
+	return d
+}
+
+func envInt(key string, def int) int {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return def
+	}
+	return n
+}
+
+func envString(key, def string) string {
+	if v, ok := os.LookupEnv(key); ok && v != "" {
+		return v
+	}
+	return def
+}
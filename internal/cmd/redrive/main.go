@@ -0,0 +1,95 @@
+// Command redrive is a standalone CLI for manually draining a dead-letter
+// queue, using the same classification logic as the consumer's own
+// scheduled dlq.DLQConsumer. It's meant for an operator to run by hand
+// against a queue that's backed up, rather than waiting on
+// DLQPollInterval or the admin API's POST /admin/dlq/redrive.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"go.uber.org/zap"
+
+	"sqs-fargate-consumer/internal/awsclient"
+	"sqs-fargate-consumer/internal/consumer"
+	"sqs-fargate-consumer/internal/consumer/dlq"
+)
+
+func main() {
+	dlqURL := flag.String("dlq-url", "", "dead-letter queue URL to drain (required)")
+	mainQueueURL := flag.String("main-queue-url", "", "queue to redrive non-poison messages back onto (required)")
+	archiveBucket := flag.String("archive-bucket", "", "S3 bucket to archive poison messages to (required)")
+	maxRetries := flag.Int("max-retries", 5, "attempts before a message is classified as poison")
+	batchSize := flag.Int("batch-size", 10, "messages drained per Drain call")
+	total := flag.Int("total", 100, "total messages to drain; 0 drains until the queue is empty")
+	rate := flag.Float64("rate", 5, "batches per second to drain at")
+	flag.Parse()
+
+	if *dlqURL == "" || *mainQueueURL == "" || *archiveBucket == "" {
+		log.Fatal("redrive: -dlq-url, -main-queue-url, and -archive-bucket are required")
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer cancel()
+
+	awscfg, err := awsclient.LoadConfig(ctx, awsclient.ConfigFromEnv("REDRIVE"))
+	if err != nil {
+		log.Fatalf("redrive: unable to load SDK config: %v", err)
+	}
+
+	logger, err := zap.NewProduction()
+	if err != nil {
+		log.Fatalf("redrive: unable to build logger: %v", err)
+	}
+	defer logger.Sync()
+
+	metricsCollector := consumer.NewMetricsCollector(cloudwatch.NewFromConfig(awscfg), "SQSConsumerRedrive", &consumer.Config{}, logger)
+	dlqConsumer := dlq.New(sqs.NewFromConfig(awscfg), s3.NewFromConfig(awscfg), metricsCollector, dlq.Config{
+		QueueName:     "redrive-cli",
+		DLQURL:        *dlqURL,
+		MainQueueURL:  *mainQueueURL,
+		ArchiveBucket: *archiveBucket,
+		MaxRetries:    *maxRetries,
+	})
+
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / *rate))
+	defer ticker.Stop()
+
+	var moved int
+	for *total <= 0 || moved < *total {
+		select {
+		case <-ctx.Done():
+			log.Printf("redrive: interrupted after moving %d message(s)", moved)
+			return
+		case <-ticker.C:
+		}
+
+		batch := *batchSize
+		if *total > 0 && *total-moved < batch {
+			batch = *total - moved
+		}
+
+		result, err := dlqConsumer.Drain(ctx, batch)
+		if err != nil {
+			log.Fatalf("redrive: drain failed after moving %d message(s): %v", moved, err)
+		}
+
+		moved += result.Redriven + result.Archived
+		log.Printf("redrive: redriven=%d archived=%d total_moved=%d", result.Redriven, result.Archived, moved)
+
+		if result.Redriven+result.Archived == 0 {
+			log.Printf("redrive: queue is empty, stopping after moving %d message(s)", moved)
+			return
+		}
+	}
+
+	log.Printf("redrive: done, moved %d message(s)", moved)
+}
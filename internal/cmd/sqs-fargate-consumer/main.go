@@ -3,50 +3,193 @@ package main
 import (
 	"context"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
+	"sqs-fargate-consumer/internal/awsclient"
 	"sqs-fargate-consumer/internal/consumer"
+	"sqs-fargate-consumer/internal/consumer/crashreport"
+	"sqs-fargate-consumer/internal/consumer/dlq"
+	"sqs-fargate-consumer/internal/consumer/leader"
+	"sqs-fargate-consumer/internal/consumer/sqsdlq"
+	"sqs-fargate-consumer/internal/consumer/sqssource"
+	"sqs-fargate-consumer/internal/telemetry"
 	"sqs-fargate-consumer/internal/utils"
 	"syscall"
 	"time"
 
-	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
 )
 
 func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	// Load AWS configuration
-	awscfg, err := config.LoadDefaultConfig(ctx)
+	logger, err := zap.NewProduction()
+	if err != nil {
+		log.Fatalf("unable to build logger: %v", err)
+	}
+	defer logger.Sync()
+
+	// Init is a no-op (and OTEL_EXPORTER_OTLP_ENDPOINT may be left unset)
+	// for deployments that only want CloudWatch/Prometheus metrics.
+	otelShutdown, err := telemetry.Init(ctx, telemetry.Config{
+		ServiceName:  "sqs-fargate-consumer",
+		OTLPEndpoint: os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"),
+		Insecure:     os.Getenv("OTEL_EXPORTER_OTLP_INSECURE") == "true",
+	})
+	if err != nil {
+		log.Fatalf("unable to init telemetry: %v", err)
+	}
+	defer otelShutdown(context.Background())
+
+	// Load AWS configuration. SQS gets its own http.Client tuned for its
+	// long-poll requests (up to 20s); everything else shares a
+	// shorter-timeout config sized for ordinary request/response calls.
+	awscfg, err := awsclient.LoadConfig(ctx, awsclient.ConfigFromEnv("CW"))
 	if err != nil {
 		log.Fatalf("unable to load SDK config: %v", err)
 	}
+	sqsClientCfg := awsclient.ConfigFromEnv("SQS")
+	if _, overridden := os.LookupEnv("SQS_HTTP_TIMEOUT"); !overridden {
+		// The shared default is sized for request/response calls and is
+		// shorter than SQS's own long-poll wait, which would otherwise
+		// time the client out before a WaitTimeSeconds=20 ReceiveMessage
+		// against an empty queue could return.
+		sqsClientCfg.Timeout = 25 * time.Second
+	}
+	sqsAWSCfg, err := awsclient.LoadConfig(ctx, sqsClientCfg)
+	if err != nil {
+		log.Fatalf("unable to load SQS SDK config: %v", err)
+	}
+
+	const mainQueueURL = "https://sqs.us-west-2.amazonaws.com/982600293865/sqs-fargate-consumer-eventqueue"
+	const mainQueueVisibilityTimeout = 30 * time.Second
+
+	sqsClient := sqs.NewFromConfig(sqsAWSCfg)
+	mainQueueSource := sqssource.New(sqsClient, sqssource.Config{URL: mainQueueURL, VisibilityTimeout: mainQueueVisibilityTimeout})
 
 	cfg := &consumer.Config{
-		QueueURL:             "https://sqs.us-west-2.amazonaws.com/982600293865/sqs-fargate-consumer-eventqueue",
-		InitialWorkerCount:   5,
-		MaxWorkerCount:       100,
-		MinWorkerCount:       2,
-		ScaleUpThreshold:     50,
-		ScaleDownThreshold:   10,
+		Queues: []consumer.QueueConfig{
+			{
+				Name:               "main",
+				Source:             mainQueueSource,
+				InitialWorkerCount: 5,
+				MinWorkers:         2,
+				MaxWorkers:         100,
+				Priority:           10,
+				ScaleUpThreshold:   50,
+				ScaleDownThreshold: 10,
+			},
+		},
+		MaxTotalWorkers:      100,
 		CooldownPeriod:       30 * time.Second,
 		TargetProcessingTime: 30 * time.Second,
+		LeaderElectionTable:  "sqs-fargate-consumer-leases",
+		LeaseTTL:             15 * time.Second,
+		LeaseRenewInterval:   5 * time.Second,
+		MetricsBackends:      []string{consumer.BackendCloudWatch, consumer.BackendPrometheus, consumer.BackendOTel},
+		DLQMaxRetries:        5,
+		// FailurePolicyExplicitDLQ moves a message to DLQURL itself once it
+		// hits DLQMaxRetries, instead of leaving that entirely to SQS's own
+		// redrive policy.
+		FailurePolicy:       consumer.FailurePolicy{Mode: consumer.FailurePolicyExplicitDLQ},
+		DLQURL:              "https://sqs.us-west-2.amazonaws.com/982600293865/sqs-fargate-consumer-eventqueue-dlq",
+		DLQArchiveBucket:    "sqs-fargate-consumer-dlq-archive",
+		DLQPollInterval:     30 * time.Second,
+		AdminToken:          os.Getenv("ADMIN_TOKEN"),
+		AdminMTLSCACertName: os.Getenv("ADMIN_MTLS_CA_CERT"),
+		CrashReport: consumer.CrashReportConfig{
+			Dir:       "/var/lib/sqs-fargate-consumer/crashreports",
+			MaxFiles:  200,
+			MaxSizeMB: 100,
+			SentryDSN: os.Getenv("SENTRY_DSN"),
+			RedactPII: true,
+		},
 	}
 
-	// Health and ready check server
-	go utils.StartHTTPSServer()
-
 	// Initialize components
-	sqsClient := sqs.NewFromConfig(awscfg)
 	cwClient := cloudwatch.NewFromConfig(awscfg)
-	metricsCollector := consumer.NewMetricsCollector(cwClient, "SQSConsumer")
-	sqsConsumer := consumer.NewConsumer(sqsClient, metricsCollector, cfg)
-	scaler := consumer.NewScaler(metricsCollector, sqsConsumer, cfg)
+	metricsCollector := consumer.NewMetricsCollector(cwClient, "SQSConsumer", cfg, logger)
+
+	var crashReporter consumer.CrashReporter
+	if cfg.CrashReport.Dir != "" || cfg.CrashReport.SentryDSN != "" {
+		reporter, err := crashreport.New(crashreport.Config{
+			Dir:                 cfg.CrashReport.Dir,
+			MaxFiles:            cfg.CrashReport.MaxFiles,
+			MaxSizeMB:           cfg.CrashReport.MaxSizeMB,
+			SentryDSN:           cfg.CrashReport.SentryDSN,
+			RedactPII:           cfg.CrashReport.RedactPII,
+			MaxMessageBodyBytes: cfg.CrashReport.MaxMessageBodyBytes,
+		})
+		if err != nil {
+			log.Fatalf("unable to init crash reporter: %v", err)
+		}
+		crashReporter = reporter
+	}
+
+	consumerOpts := []consumer.Option{consumer.WithLogger(logger)}
+	if cfg.DLQURL != "" && cfg.FailurePolicy.Mode == consumer.FailurePolicyExplicitDLQ {
+		consumerOpts = append(consumerOpts, consumer.WithDLQClient(sqsdlq.New(sqsClient, cfg.DLQURL)))
+	}
+	sqsConsumer := consumer.NewConsumer(metricsCollector, cfg, crashReporter, consumerOpts...)
+
+	extraRoutes := map[string]http.Handler{}
+	if cfg.DLQURL != "" {
+		dlqConsumer := dlq.New(sqsClient, s3.NewFromConfig(awscfg), metricsCollector, dlq.Config{
+			QueueName:     "main",
+			DLQURL:        cfg.DLQURL,
+			MainQueueURL:  mainQueueURL,
+			ArchiveBucket: cfg.DLQArchiveBucket,
+			MaxRetries:    cfg.DLQMaxRetries,
+			PollInterval:  cfg.DLQPollInterval,
+		})
+		extraRoutes["/admin/dlq/redrive"] = dlqConsumer.RedriveHandler(cfg.AdminToken)
+		go dlqConsumer.Run(ctx)
+	}
+
+	sqsConsumer.Use(
+		consumer.RecoverMiddleware(metricsCollector),
+		consumer.MetricsMiddleware(metricsCollector),
+		consumer.LoggingMiddleware(logger),
+		consumer.RetryMiddleware(2, 2*time.Second),
+		sqssource.ChangeVisibilityOnSlow(sqsClient, mainQueueVisibilityTimeout, 5*time.Second, mainQueueVisibilityTimeout),
+	)
+	sqsConsumer.Fallback(consumer.HandlerFunc(func(ctx context.Context, msg consumer.Message) error {
+		log.Printf("Processing message %s", msg.ID)
+		time.Sleep(5 * time.Second) // Simulate processing time
+		return nil
+	}))
+
+	var elector consumer.LeaderElector
+	if cfg.LeaderElectionTable != "" {
+		identity := uuid.New().String()
+		elector = leader.New(dynamodb.NewFromConfig(awscfg), leader.Config{
+			TableName:     cfg.LeaderElectionTable,
+			LeaseKey:      "sqs-fargate-consumer-scaler",
+			Identity:      identity,
+			LeaseTTL:      cfg.LeaseTTL,
+			RenewInterval: cfg.LeaseRenewInterval,
+		})
+	}
+
+	scaler := consumer.NewScaler(metricsCollector, sqsConsumer, cfg, elector, logger)
+
+	adminServer := consumer.NewAdminServer(sqsConsumer, metricsCollector, scaler, cfg.AdminToken)
+	for pattern, handler := range adminServer.Routes() {
+		extraRoutes[pattern] = handler
+	}
+
+	// Health, ready, Prometheus scrape (when enabled), and admin endpoints
+	go utils.StartHTTPSServer(metricsCollector.Registry(), extraRoutes, cfg.AdminMTLSCACertName)
 
 	// Start the consumer system
+	go mainQueueSource.Run(ctx)
 	go sqsConsumer.Start(ctx)
 	go metricsCollector.Start(ctx)
 	go scaler.Start(ctx)
@@ -0,0 +1,241 @@
+package consumer
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// AdminServer exposes read-only introspection and incident-response control
+// over a running Consumer: worker status, per-queue depth, raw metric
+// windows, a manual scale override that bypasses the scaler's cooldown, and
+// a graceful drain for Fargate SIGTERM handlers. Mount Routes into
+// utils.StartHTTPSServer's extraRoutes; that server should ideally also be
+// configured for mTLS, but when a token is set every route Routes returns
+// additionally requires a matching X-Admin-Token header itself (the same
+// check consumer/dlq.DLQConsumer.RedriveHandler uses) so these endpoints
+// aren't left open if adminCACertName is ever left unset. With an empty
+// token and no mTLS, the routes are unprotected.
+type AdminServer struct {
+	consumer  *Consumer
+	collector *MetricsCollector
+	scaler    *Scaler
+	token     string
+}
+
+// NewAdminServer creates an AdminServer over the given Consumer, its
+// MetricsCollector, and its Scaler. If token is non-empty, every route
+// Routes returns requires a matching X-Admin-Token header.
+func NewAdminServer(consumer *Consumer, collector *MetricsCollector, scaler *Scaler, token string) *AdminServer {
+	return &AdminServer{consumer: consumer, collector: collector, scaler: scaler, token: token}
+}
+
+// Routes returns the admin endpoints keyed by URL pattern, ready to merge
+// into utils.StartHTTPSServer's extraRoutes.
+func (a *AdminServer) Routes() map[string]http.Handler {
+	routes := map[string]http.HandlerFunc{
+		"/admin/workers":        a.handleWorkers,
+		"/admin/metrics/window": a.handleMetricsWindow,
+		"/admin/queues":         a.handleQueues,
+		"/admin/scale":          a.handleScale,
+		"/admin/drain":          a.handleDrain,
+	}
+
+	out := make(map[string]http.Handler, len(routes))
+	for pattern, handler := range routes {
+		out[pattern] = a.requireToken(handler)
+	}
+	return out
+}
+
+// requireToken wraps next so a request is rejected unless it carries an
+// X-Admin-Token header matching a.token. A zero-value token leaves the
+// route unprotected, matching consumer/dlq.DLQConsumer.RedriveHandler's
+// behavior for the same header.
+func (a *AdminServer) requireToken(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if a.token != "" && r.Header.Get("X-Admin-Token") != a.token {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// workerStatus is the JSON shape GET /admin/workers returns for one worker.
+type workerStatus struct {
+	Queue          string    `json:"queue"`
+	WorkerID       string    `json:"worker_id"`
+	IsProcessing   bool      `json:"is_processing"`
+	ProcessedCount int64     `json:"processed_count"`
+	LastMessageAt  time.Time `json:"last_message_at,omitempty"`
+}
+
+func (a *AdminServer) handleWorkers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	statuses := make([]workerStatus, 0)
+	for name, pool := range a.consumer.pools {
+		statuses = append(statuses, pool.workerStatuses(name)...)
+	}
+
+	writeJSON(w, statuses)
+}
+
+// queueStatus is the JSON shape GET /admin/queues returns for one queue.
+type queueStatus struct {
+	Queue    string  `json:"queue"`
+	Depth    float64 `json:"depth"`
+	InFlight float64 `json:"in_flight"`
+}
+
+func (a *AdminServer) handleQueues(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	statuses := make([]queueStatus, 0, len(a.consumer.pools))
+	for name := range a.consumer.pools {
+		statuses = append(statuses, queueStatus{
+			Queue:    name,
+			Depth:    a.collector.GetMetric(MetricQueueDepth, name),
+			InFlight: a.collector.GetMetric(MetricInFlightMessages, name),
+		})
+	}
+
+	writeJSON(w, statuses)
+}
+
+// handleMetricsWindow serves GET /admin/metrics/window?name=X&duration=5m[&queue=Y],
+// returning the raw sliding-window datapoints MetricsCollector has for that
+// metric. duration defaults to 5m and accepts anything time.ParseDuration
+// does.
+func (a *AdminServer) handleMetricsWindow(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		http.Error(w, "name is required", http.StatusBadRequest)
+		return
+	}
+
+	duration := 5 * time.Minute
+	if v := r.URL.Query().Get("duration"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			http.Error(w, "invalid duration", http.StatusBadRequest)
+			return
+		}
+		duration = d
+	}
+
+	writeJSON(w, a.collector.Window(name, r.URL.Query().Get("queue"), duration))
+}
+
+// handleScale serves POST /admin/scale?queue=X&workers=N, an incident-response
+// override that scales queue X to exactly N workers (clamped to its
+// MinWorkers/MaxWorkers) right away, bypassing the scaler's cooldown, then
+// resets the cooldown so the scaler doesn't immediately react to it.
+func (a *AdminServer) handleScale(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	queue := r.URL.Query().Get("queue")
+	pool, ok := a.consumer.pools[queue]
+	if !ok {
+		http.Error(w, fmt.Sprintf("unknown queue %q", queue), http.StatusBadRequest)
+		return
+	}
+
+	target, err := strconv.Atoi(r.URL.Query().Get("workers"))
+	if err != nil {
+		http.Error(w, "invalid workers", http.StatusBadRequest)
+		return
+	}
+	if target < pool.config.MinWorkers {
+		target = pool.config.MinWorkers
+	}
+	if target > pool.config.MaxWorkers {
+		target = pool.config.MaxWorkers
+	}
+
+	for pool.workerCount() < target {
+		if err := a.consumer.addWorker(queue); err != nil {
+			log.Printf("admin: scale up of queue %q stopped early: %v", queue, err)
+			break
+		}
+	}
+	for pool.workerCount() > target {
+		if err := a.consumer.removeWorker(queue); err != nil {
+			log.Printf("admin: scale down of queue %q stopped early: %v", queue, err)
+			break
+		}
+	}
+
+	if a.scaler != nil {
+		a.scaler.ResetCooldown()
+	}
+
+	writeJSON(w, map[string]int{"workers": pool.workerCount()})
+}
+
+// drainPollInterval and drainTimeout bound how long POST /admin/drain waits
+// for in-flight messages to finish before giving up.
+const (
+	drainPollInterval = 500 * time.Millisecond
+	drainTimeout      = 2 * time.Minute
+)
+
+// handleDrain serves POST /admin/drain: it stops every worker from polling
+// for new messages, then blocks until every in-flight message has finished
+// (or drainTimeout elapses), returning 200 once the consumer is idle. Meant
+// to be called from a Fargate SIGTERM handler before the task exits.
+func (a *AdminServer) handleDrain(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	a.consumer.drain()
+
+	ctx := r.Context()
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+	timeout := time.NewTimer(drainTimeout)
+	defer timeout.Stop()
+
+	for {
+		if a.consumer.activeWorkerCount() == 0 {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-timeout.C:
+			http.Error(w, "drain did not complete before timeout", http.StatusGatewayTimeout)
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("admin: failed to write response: %v", err)
+	}
+}
@@ -3,50 +3,303 @@ package consumer
 import (
 	"context"
 	"fmt"
-	"strconv"
+	"runtime/debug"
 	"sync"
 	"time"
 
-	"github.com/aws/aws-sdk-go-v2/service/sqs"
-	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
 	"github.com/google/uuid"
+	"go.uber.org/zap"
 )
 
 type Config struct {
-	QueueURL             string
-	InitialWorkerCount   int
-	MaxWorkerCount       int
-	MinWorkerCount       int
-	ScaleUpThreshold     float64
-	ScaleDownThreshold   float64
+	// Queues lists every SQS queue this consumer polls. Each entry owns its
+	// own worker bounds, scaling thresholds, and priority weight; there is
+	// no single global queue.
+	Queues []QueueConfig
+	// MaxTotalWorkers caps the combined worker count across all queues under
+	// ScalingModeThreshold: Scaler divides this budget across queues
+	// proportionally to priority (priority / sum of all priorities), clamped
+	// to each queue's own MinWorkers/MaxWorkers. Zero or negative means
+	// unbounded: each queue may scale up to its own MaxWorkers independently.
+	// The predictive mode does not read it; it sizes each queue within that
+	// queue's own MinWorkers/MaxWorkers and does not cap the combined count.
+	MaxTotalWorkers int
+
 	CooldownPeriod       time.Duration
 	TargetProcessingTime time.Duration
+
+	// ScalingMode selects how Scaler sizes worker pools: ScalingModePredictive
+	// (the default) runs an EWMA/Little's-Law controller; ScalingModeThreshold
+	// falls back to the older reactive ScaleUpThreshold/ScaleDownThreshold
+	// comparison, kept for deployments that already tuned those thresholds.
+	ScalingMode string
+	// EWMAAlpha smooths the predictive scaler's arrival-rate and service-time
+	// estimates; higher weights recent samples more. Defaults to 0.3.
+	EWMAAlpha float64
+	// TargetUtilization is the fraction of each worker's capacity the
+	// predictive scaler aims to keep busy when sizing a queue's pool via
+	// Little's Law. Defaults to 0.7.
+	TargetUtilization float64
+	// ScalingDeadband is the fractional difference between the predictive
+	// scaler's target worker count and the current count required before it
+	// acts, so small estimation noise doesn't thrash the pool. Defaults to
+	// 0.15 (15%).
+	ScalingDeadband float64
+
+	// DLQMaxRetries caps the exponential backoff a worker applies when it
+	// requeues a failed message (see Worker.handleError). It should match
+	// the maxReceiveCount configured on the queue's redrive policy so a
+	// message's backoff keeps pace with how many attempts it has left
+	// before SQS moves it to the dead-letter queue. It also doubles as
+	// FailurePolicy's MaxReceives when that field is left zero.
+	DLQMaxRetries int
+	// FailurePolicy controls what a worker does with a message whose
+	// handler returned an error. The zero value behaves as
+	// FailurePolicyBackoff, matching this package's historical behavior.
+	FailurePolicy FailurePolicy
+	// DLQURL, when set, is the dead-letter queue a consumer/dlq.DLQConsumer
+	// drains on a schedule. Leave empty to skip running one.
+	DLQURL string
+	// DLQArchiveBucket is the S3 bucket the DLQ consumer archives poison
+	// messages to.
+	DLQArchiveBucket string
+	// DLQPollInterval controls how often the DLQ consumer drains DLQURL.
+	DLQPollInterval time.Duration
+	// AdminToken, when set, is the shared secret POST /admin/dlq/redrive
+	// requires in an X-Admin-Token header.
+	AdminToken string
+	// AdminMTLSCACertName, when set, names the secret holding the CA bundle
+	// utils.StartHTTPSServer uses to require a client certificate on every
+	// endpoint it serves, protecting AdminServer's routes.
+	AdminMTLSCACertName string
+
+	// CrashReport configures how a worker-goroutine panic is persisted and
+	// reported; see consumer/crashreport for the bundled implementation.
+	CrashReport CrashReportConfig
+
+	// LeaderElectionTable, when set, enables leader-elected scaling: the
+	// DynamoDB table backing the lease used to pick the single task that is
+	// allowed to make scaling decisions. Leave empty to disable election and
+	// have every task scale independently (single-task deployments).
+	LeaderElectionTable string
+	LeaseTTL            time.Duration
+	LeaseRenewInterval  time.Duration
+
+	// MetricsBackends selects which backends MetricsCollector publishes to:
+	// any combination of "cloudwatch", "prometheus", and "otel". Defaults to
+	// ["cloudwatch"].
+	MetricsBackends []string
+	// ProcessingTimeBuckets overrides the Prometheus histogram buckets used
+	// for the processing-time metric. Defaults to prometheus.DefBuckets.
+	ProcessingTimeBuckets []float64
+}
+
+// QueueConfig describes one SQS queue a Consumer polls: where it lives, how
+// many workers it may run, and how it competes for the shared worker budget
+// against the other queues.
+type QueueConfig struct {
+	// Name identifies the queue in logs and metrics (CloudWatch dimension,
+	// Prometheus label). It does not need to match the queue's SQS name.
+	Name string
+
+	InitialWorkerCount int
+	MinWorkers         int
+	MaxWorkers         int
+
+	// Priority weights this queue's share of Config.MaxTotalWorkers
+	// relative to the other queues: higher priority gets more of the
+	// shared budget when several queues have backlog at once.
+	Priority int
+
+	// ScaleUpThreshold and ScaleDownThreshold are only consulted under
+	// Config.ScalingMode == ScalingModeThreshold; the default predictive mode
+	// sizes the pool from arrival rate and service time instead.
+	ScaleUpThreshold   float64
+	ScaleDownThreshold float64
+
+	// Source is the broker this queue's workers poll: sqssource.New,
+	// kafkasource.New, or memsource.New, wired by main.go based on which
+	// broker this queue's messages actually come from.
+	Source Source
+	// BatchSize caps how many messages a worker requests per Source.Receive
+	// call. Defaults to 10 when zero.
+	BatchSize int32
+}
+
+// FailurePolicyMode selects what a worker does with a message whose handler
+// returned an error; see FailurePolicy.
+type FailurePolicyMode string
+
+const (
+	// FailurePolicyNatural leaves the message alone, neither nacking nor
+	// acking it: its visibility timeout expires on its own and the broker
+	// redelivers it through its normal mechanism.
+	FailurePolicyNatural FailurePolicyMode = "natural"
+	// FailurePolicyBackoff nacks the message with an exponential
+	// visibility-extension delay based on its attempt count (see
+	// Worker.backoffDelay). This is the zero value's behavior.
+	FailurePolicyBackoff FailurePolicyMode = "backoff"
+	// FailurePolicyExplicitDLQ behaves like FailurePolicyBackoff until the
+	// message's attempt count reaches FailurePolicy.MaxReceives, at which
+	// point the worker moves it to the configured DLQClient itself instead
+	// of waiting for the broker's own redrive policy to notice.
+	FailurePolicyExplicitDLQ FailurePolicyMode = "explicit-dlq"
+)
+
+// FailurePolicy controls what Worker.handleError does with a message whose
+// handler returned an error.
+type FailurePolicy struct {
+	Mode FailurePolicyMode
+	// MaxReceives caps how many times a message may be attempted under
+	// FailurePolicyExplicitDLQ before it's moved to the dead-letter queue
+	// directly. Zero or negative falls back to Config.DLQMaxRetries.
+	MaxReceives int
+}
+
+// DLQClient moves a message directly to a dead-letter destination. Worker
+// uses one under FailurePolicyExplicitDLQ once a message has exceeded
+// FailurePolicy.MaxReceives, rather than waiting for the broker's own
+// server-side redrive policy to move it there. See consumer/sqsdlq for the
+// SQS-backed implementation.
+type DLQClient interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// CrashReportConfig configures the bundled consumer/crashreport.Reporter.
+// Leave Dir empty to skip the local spool, and SentryDSN empty to skip
+// forwarding to Sentry; both may be set at once.
+type CrashReportConfig struct {
+	// Dir is where crash reports are written as JSON files.
+	Dir string
+	// MaxFiles caps how many reports are kept in Dir, oldest evicted first.
+	MaxFiles int
+	// MaxSizeMB caps the combined size of Dir's contents in megabytes,
+	// oldest evicted first.
+	MaxSizeMB int64
+	// SentryDSN, when set, forwards every report to Sentry.
+	SentryDSN string
+	// RedactPII scrubs common PII patterns (emails, phone numbers) from a
+	// report's message body before it's persisted or forwarded.
+	RedactPII bool
+	// MaxMessageBodyBytes caps how much of a report's message body is kept;
+	// the rest is dropped before redaction. Defaults to 4096.
+	MaxMessageBodyBytes int
 }
 
 type Consumer struct {
-	client           *sqs.Client
-	queueURL         string
-	workers          map[string]*Worker
+	pools            map[string]*QueuePool // keyed by QueueConfig.Name
 	metricsCollector *MetricsCollector
-	mu               sync.RWMutex
 	config           *Config
+	router           *Router
+	handler          Handler
+	crashReporter    CrashReporter
+	dlqClient        DLQClient
+	logger           *zap.Logger
+	mw               sync.Mutex
+	middlewares      []Middleware
 }
 
-func NewConsumer(client *sqs.Client, collector *MetricsCollector, config *Config) *Consumer {
-	return &Consumer{
-		client:           client,
-		queueURL:         config.QueueURL,
-		workers:          make(map[string]*Worker),
+// NewConsumer builds a Consumer with one QueuePool per config.Queues entry.
+// crashReporter may be nil, in which case a worker panic is still recovered
+// and counted in metrics but nothing is persisted or forwarded externally.
+func NewConsumer(collector *MetricsCollector, config *Config, crashReporter CrashReporter, opts ...Option) *Consumer {
+	pools := make(map[string]*QueuePool, len(config.Queues))
+	for _, qc := range config.Queues {
+		pools[qc.Name] = newQueuePool(qc)
+	}
+
+	c := &Consumer{
+		pools:            pools,
 		metricsCollector: collector,
 		config:           config,
+		router:           NewRouter(),
+		crashReporter:    crashReporter,
+		logger:           defaultLogger(),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// defaultLogger is used when NewConsumer isn't given a WithLogger option, so
+// Consumer and the workers it spawns always have a non-nil logger to write
+// to.
+func defaultLogger() *zap.Logger {
+	logger, err := zap.NewProduction()
+	if err != nil {
+		return zap.NewNop()
+	}
+	return logger
+}
+
+// Option configures optional Consumer behavior at construction time.
+type Option func(*Consumer)
+
+// WithHandler sets h as the Consumer's single handler for every message on
+// every queue, bypassing Router entirely. Use it when the application has
+// one handler and doesn't need Handle/Fallback's per-event-type dispatch; any
+// registrations made through Handle or Fallback are ignored once a handler is
+// set this way.
+func WithHandler(h Handler) Option {
+	return func(c *Consumer) {
+		c.handler = h
+	}
+}
+
+// WithDLQClient sets the client a worker moves a message to under
+// Config.FailurePolicy's FailurePolicyExplicitDLQ mode. Required for that
+// mode; ignored otherwise.
+func WithDLQClient(client DLQClient) Option {
+	return func(c *Consumer) {
+		c.dlqClient = client
 	}
 }
 
+// WithLogger sets the structured logger Consumer and the workers it spawns
+// use in place of the standard library's log package. Defaults to a
+// zap.NewProduction logger (or a no-op logger if that fails to build) when
+// left unset.
+func WithLogger(logger *zap.Logger) Option {
+	return func(c *Consumer) {
+		c.logger = logger
+	}
+}
+
+// Logger returns the structured logger Consumer was configured with (see
+// WithLogger), for callers like Scaler and LoggingMiddleware that share it.
+func (c *Consumer) Logger() *zap.Logger {
+	return c.logger
+}
+
+// Use registers middleware applied, in order, to every message any worker on
+// any queue handles. Use must be called before Start so the chain is in
+// place before workers are spun up.
+func (c *Consumer) Use(mw ...Middleware) {
+	c.mw.Lock()
+	defer c.mw.Unlock()
+	c.middlewares = append(c.middlewares, mw...)
+}
+
+// Handle registers h to process messages whose event type is eventType, as
+// read from the "event_type" message attribute.
+func (c *Consumer) Handle(eventType string, h Handler) {
+	c.router.Handle(eventType, h)
+}
+
+// Fallback registers h to process messages whose event type matches no
+// handler registered via Handle.
+func (c *Consumer) Fallback(h Handler) {
+	c.router.Fallback(h)
+}
+
 func (c *Consumer) Start(ctx context.Context) error {
-	// Start initial workers
-	for i := 0; i < c.config.InitialWorkerCount; i++ {
-		if err := c.addWorker(); err != nil {
-			return fmt.Errorf("failed to start initial workers: %w", err)
+	// Start each queue's initial workers
+	for name, pool := range c.pools {
+		for i := 0; i < pool.config.InitialWorkerCount; i++ {
+			if err := c.addWorker(name); err != nil {
+				return fmt.Errorf("failed to start initial workers for queue %q: %w", name, err)
+			}
 		}
 	}
 
@@ -68,77 +321,151 @@ func (c *Consumer) monitorQueueDepth(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			attrs, err := c.client.GetQueueAttributes(ctx, &sqs.GetQueueAttributesInput{
-				QueueUrl: &c.queueURL,
-				AttributeNames: []types.QueueAttributeName{
-					types.QueueAttributeNameApproximateNumberOfMessages,
-					types.QueueAttributeNameApproximateNumberOfMessagesNotVisible,
-				},
-			})
-
-			if err != nil {
-				c.metricsCollector.RecordError("queue_depth_fetch_error")
-				continue
-			}
-
-			// Get visible messages
-			if visibleStr, ok := attrs.Attributes[string(types.QueueAttributeNameApproximateNumberOfMessages)]; ok {
-				visible, err := strconv.Atoi(visibleStr)
-				if err == nil {
-					c.metricsCollector.RecordQueueDepth(visible)
-				} else {
-					c.metricsCollector.RecordError("queue_depth_parse_error")
-				}
-			}
-
-			// Get in-flight messages
-			if notVisibleStr, ok := attrs.Attributes[string(types.QueueAttributeNameApproximateNumberOfMessagesNotVisible)]; ok {
-				notVisible, err := strconv.Atoi(notVisibleStr)
-				if err == nil {
-					c.metricsCollector.RecordMetric("InFlightMessages", float64(notVisible), "Count")
-				} else {
-					c.metricsCollector.RecordError("in_flight_messages_parse_error")
-				}
+			for _, pool := range c.pools {
+				c.pollQueueDepth(pool.config)
 			}
 		}
 	}
 }
 
-func (c *Consumer) addWorker() error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+// pollQueueDepth reads qc.Source's cached backlog and records it as the
+// queue-depth and in-flight-message metrics the scaler's threshold checks
+// and GET /admin/queues both read back out of MetricsCollector.
+func (c *Consumer) pollQueueDepth(qc QueueConfig) {
+	stats := qc.Source.Stats()
+	c.metricsCollector.RecordQueueDepth(qc.Name, stats.ApproximateNumberOfMessages)
+	c.metricsCollector.RecordMetric(MetricInFlightMessages, float64(stats.ApproximateNumberOfMessagesNotVisible), "Count", qc.Name)
+}
+
+func (c *Consumer) addWorker(queueName string) error {
+	pool, ok := c.pools[queueName]
+	if !ok {
+		return fmt.Errorf("unknown queue %q", queueName)
+	}
 
-	if len(c.workers) >= c.config.MaxWorkerCount {
-		return fmt.Errorf("max worker count reached")
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	if len(pool.workers) >= pool.config.MaxWorkers {
+		return fmt.Errorf("max worker count reached for queue %q", queueName)
 	}
 
 	workerID := uuid.New().String()
-	worker := NewWorker(workerID, c.client, c.queueURL, c.metricsCollector)
-	c.workers[workerID] = worker
+	base := c.handler
+	if base == nil {
+		base = HandlerFunc(c.router.Route)
+	}
+	handler := ApplyDecorators(base, c.middlewares...)
+	worker := NewWorker(workerID, pool.config.Source, queueName, c.metricsCollector, handler, c.config.DLQMaxRetries, pool.config, c.config.FailurePolicy, c.dlqClient, c.logger)
+	pool.workers[workerID] = worker
 
-	go worker.Start(context.Background())
+	go c.runWorker(queueName, worker)
 
 	return nil
 }
 
-func (c *Consumer) removeWorker() error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+// CrashReporter receives a report whenever a worker goroutine panics. See
+// consumer/crashreport for the bundled disk-spool-and-Sentry implementation.
+type CrashReporter interface {
+	Report(CrashReport)
+}
+
+// CrashReport describes a single worker panic: enough to reproduce it
+// offline (the message that triggered it, truncated) and enough to triage it
+// quickly (where it happened and what crashed).
+type CrashReport struct {
+	Queue       string
+	WorkerID    string
+	PanicValue  string
+	Stack       []byte
+	MessageID   string
+	MessageBody string
+	Time        time.Time
+}
+
+// runWorker runs worker.Start, recovering from any panic so one bad message
+// can't take down the whole Fargate task. On a panic it records a PanicCount
+// metric, forwards a CrashReport to c.crashReporter if one is configured, and
+// respawns a replacement worker on the same queue so the pool's size holds
+// steady.
+func (c *Consumer) runWorker(queueName string, worker *Worker) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+
+		panicType := fmt.Sprintf("%T", r)
+		c.metricsCollector.RecordPanicCount(queueName, panicType)
+		c.logger.Error("worker panicked",
+			zap.String("worker_id", worker.id),
+			zap.String("queue", queueName),
+			zap.Any("panic", r),
+			zap.ByteString("stack", debug.Stack()),
+		)
+
+		if c.crashReporter != nil {
+			report := CrashReport{
+				Queue:      queueName,
+				WorkerID:   worker.id,
+				PanicValue: fmt.Sprintf("%v", r),
+				Stack:      debug.Stack(),
+				Time:       time.Now(),
+			}
+			if msg := worker.CurrentMessage(); msg != nil {
+				report.MessageID = msg.ID
+				report.MessageBody = msg.Body
+			}
+			c.crashReporter.Report(report)
+		}
+
+		c.respawnWorker(queueName, worker.id)
+	}()
+
+	worker.Start(context.Background())
+}
+
+// respawnWorker replaces a worker that panicked with a fresh one on the same
+// queue. deadWorkerID is removed first so addWorker's MaxWorkers check isn't
+// counting a worker that's already gone.
+func (c *Consumer) respawnWorker(queueName, deadWorkerID string) {
+	pool, ok := c.pools[queueName]
+	if !ok {
+		return
+	}
+
+	pool.mu.Lock()
+	delete(pool.workers, deadWorkerID)
+	pool.mu.Unlock()
+
+	if err := c.addWorker(queueName); err != nil {
+		c.logger.Error("failed to respawn worker", zap.String("queue", queueName), zap.Error(err))
+	}
+}
+
+func (c *Consumer) removeWorker(queueName string) error {
+	pool, ok := c.pools[queueName]
+	if !ok {
+		return fmt.Errorf("unknown queue %q", queueName)
+	}
+
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
 
-	if len(c.workers) <= c.config.MinWorkerCount {
-		return fmt.Errorf("min worker count reached")
+	if len(pool.workers) <= pool.config.MinWorkers {
+		return fmt.Errorf("min worker count reached for queue %q", queueName)
 	}
 
 	// Remove least active worker
 	var workerToRemove string
-	for id := range c.workers {
+	for id := range pool.workers {
 		workerToRemove = id
 		break
 	}
 
-	if worker, exists := c.workers[workerToRemove]; exists {
+	if worker, exists := pool.workers[workerToRemove]; exists {
 		worker.Stop()
-		delete(c.workers, workerToRemove)
+		delete(pool.workers, workerToRemove)
 	}
 
 	return nil
@@ -153,31 +480,49 @@ func (c *Consumer) reportMetrics(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			c.mu.RLock()
-			workerCount := len(c.workers)
-			activeWorkers := 0
-			for _, worker := range c.workers {
-				if worker.isProcessing.Load() {
-					activeWorkers++
+			for name, pool := range c.pools {
+				workerCount := pool.workerCount()
+				c.metricsCollector.RecordWorkerCount(name, workerCount)
+
+				if workerCount > 0 {
+					activeWorkers := pool.activeWorkerCount()
+					utilization := (float64(activeWorkers) / float64(workerCount)) * 100
+					c.metricsCollector.RecordWorkerUtilization(name, utilization)
 				}
 			}
-			c.mu.RUnlock()
-
-			c.metricsCollector.RecordMetric(MetricWorkerCount, float64(workerCount), "Count")
+		}
+	}
+}
 
-			if workerCount > 0 {
-				utilization := (float64(activeWorkers) / float64(workerCount)) * 100
-				c.metricsCollector.RecordWorkerUtilization(utilization)
-			}
+// drain stops every worker from polling for new messages, letting in-flight
+// processing finish. Used by AdminServer's POST /admin/drain for graceful
+// Fargate SIGTERM handling.
+func (c *Consumer) drain() {
+	for _, pool := range c.pools {
+		pool.mu.RLock()
+		for _, worker := range pool.workers {
+			worker.Drain()
 		}
+		pool.mu.RUnlock()
 	}
 }
 
-func (c *Consumer) Shutdown() {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+// activeWorkerCount returns the total number of workers currently processing
+// a message across every queue.
+func (c *Consumer) activeWorkerCount() int {
+	total := 0
+	for _, pool := range c.pools {
+		total += pool.activeWorkerCount()
+	}
+	return total
+}
 
-	for _, worker := range c.workers {
-		worker.Stop()
+func (c *Consumer) Shutdown() {
+	for _, pool := range c.pools {
+		pool.mu.Lock()
+		for _, worker := range pool.workers {
+			worker.Stop()
+		}
+		pool.mu.Unlock()
 	}
 }
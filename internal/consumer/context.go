@@ -0,0 +1,31 @@
+package consumer
+
+import "context"
+
+type contextKey int
+
+const queueURLContextKey contextKey = iota
+
+// WithQueueURL returns a context carrying the SQS queue URL the in-flight
+// message was received from. Worker attaches this before invoking Handler so
+// a Middleware that needs to call back into SQS (e.g. sqssource's
+// ChangeVisibilityOnSlow) can find the right queue without needing its own
+// queue configuration.
+func WithQueueURL(ctx context.Context, queueURL string) context.Context {
+	return context.WithValue(ctx, queueURLContextKey, queueURL)
+}
+
+// QueueURLFromContext returns the queue URL attached by WithQueueURL, and
+// false if ctx doesn't carry one.
+func QueueURLFromContext(ctx context.Context) (string, bool) {
+	queueURL, ok := ctx.Value(queueURLContextKey).(string)
+	return queueURL, ok
+}
+
+// queueURLSource is implemented by a Source that has a stable queue address
+// worth surfacing to middleware via WithQueueURL (sqssource does). Sources
+// without one, such as kafkasource or memsource, are simply skipped by
+// Worker.processMessage.
+type queueURLSource interface {
+	QueueURL() string
+}
@@ -0,0 +1,201 @@
+// Package crashreport persists consumer.CrashReport panics to a
+// size-and-count-bounded local spool and, when configured, forwards them to
+// Sentry. Reporter implements consumer.CrashReporter.
+package crashreport
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+
+	"sqs-fargate-consumer/internal/consumer"
+)
+
+// Config configures a Reporter. An empty Config produces a Reporter that
+// silently drops every report it's given.
+type Config struct {
+	// Dir is where crash reports are written as JSON files. Leave empty to
+	// disable the local spool.
+	Dir string
+	// MaxFiles caps how many reports are kept in Dir; the oldest are
+	// evicted once the count is exceeded. Defaults to 200.
+	MaxFiles int
+	// MaxSizeMB caps the combined size of Dir's contents in megabytes; the
+	// oldest reports are evicted once the size is exceeded. Defaults to 100.
+	MaxSizeMB int64
+	// SentryDSN, when set, forwards every report to Sentry in addition to
+	// (or instead of) the local spool.
+	SentryDSN string
+	// RedactPII scrubs common PII patterns (emails, phone numbers) from a
+	// report's message body before it's persisted or forwarded.
+	RedactPII bool
+	// MaxMessageBodyBytes caps how much of a report's message body is kept;
+	// the rest is dropped before redaction. Defaults to 4096.
+	MaxMessageBodyBytes int
+}
+
+func (c *Config) setDefaults() {
+	if c.MaxFiles <= 0 {
+		c.MaxFiles = 200
+	}
+	if c.MaxSizeMB <= 0 {
+		c.MaxSizeMB = 100
+	}
+	if c.MaxMessageBodyBytes <= 0 {
+		c.MaxMessageBodyBytes = 4096
+	}
+}
+
+// Reporter implements consumer.CrashReporter.
+type Reporter struct {
+	cfg Config
+}
+
+// New creates a Reporter from cfg. It returns an error only if cfg.Dir can't
+// be created or Sentry fails to initialize.
+func New(cfg Config) (*Reporter, error) {
+	cfg.setDefaults()
+
+	if cfg.Dir != "" {
+		if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+			return nil, fmt.Errorf("create crash report dir: %w", err)
+		}
+	}
+
+	if cfg.SentryDSN != "" {
+		if err := sentry.Init(sentry.ClientOptions{Dsn: cfg.SentryDSN}); err != nil {
+			return nil, fmt.Errorf("init sentry: %w", err)
+		}
+	}
+
+	return &Reporter{cfg: cfg}, nil
+}
+
+// Report persists report to the local spool and/or forwards it to Sentry,
+// per how the Reporter was configured.
+func (r *Reporter) Report(report consumer.CrashReport) {
+	report.MessageBody = truncate(report.MessageBody, r.cfg.MaxMessageBodyBytes)
+
+	if r.cfg.RedactPII {
+		report.MessageBody = redact(report.MessageBody)
+	}
+
+	if r.cfg.SentryDSN != "" {
+		r.reportToSentry(report)
+	}
+
+	if r.cfg.Dir != "" {
+		r.writeToSpool(report)
+	}
+}
+
+func (r *Reporter) reportToSentry(report consumer.CrashReport) {
+	sentry.WithScope(func(scope *sentry.Scope) {
+		scope.SetTag("queue", report.Queue)
+		scope.SetTag("worker_id", report.WorkerID)
+		scope.SetExtra("message_id", report.MessageID)
+		scope.SetExtra("message_body", report.MessageBody)
+		scope.SetExtra("stack", string(report.Stack))
+		sentry.CaptureMessage(fmt.Sprintf("worker panic: %s", report.PanicValue))
+	})
+}
+
+// spooledReport is the JSON document written to Dir for a report.
+type spooledReport struct {
+	Queue       string    `json:"queue"`
+	WorkerID    string    `json:"worker_id"`
+	PanicValue  string    `json:"panic_value"`
+	Stack       string    `json:"stack"`
+	MessageID   string    `json:"message_id"`
+	MessageBody string    `json:"message_body"`
+	Time        time.Time `json:"time"`
+}
+
+func (r *Reporter) writeToSpool(report consumer.CrashReport) {
+	doc := spooledReport{
+		Queue:       report.Queue,
+		WorkerID:    report.WorkerID,
+		PanicValue:  report.PanicValue,
+		Stack:       string(report.Stack),
+		MessageID:   report.MessageID,
+		MessageBody: report.MessageBody,
+		Time:        report.Time,
+	}
+
+	body, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		log.Printf("crashreport: failed to marshal report: %v", err)
+		return
+	}
+
+	name := fmt.Sprintf("%d-%s.json", report.Time.UnixNano(), report.WorkerID)
+	path := filepath.Join(r.cfg.Dir, name)
+	if err := os.WriteFile(path, body, 0o644); err != nil {
+		log.Printf("crashreport: failed to write report %s: %v", path, err)
+		return
+	}
+
+	r.evict()
+}
+
+// evict removes the oldest reports in Dir until both MaxFiles and MaxSizeMB
+// are satisfied.
+func (r *Reporter) evict() {
+	entries, err := os.ReadDir(r.cfg.Dir)
+	if err != nil {
+		log.Printf("crashreport: failed to list spool dir: %v", err)
+		return
+	}
+
+	type file struct {
+		name    string
+		size    int64
+		modTime time.Time
+	}
+
+	files := make([]file, 0, len(entries))
+	var totalSize int64
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, file{name: e.Name(), size: info.Size(), modTime: info.ModTime()})
+		totalSize += info.Size()
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.Before(files[j].modTime) })
+
+	maxSizeBytes := r.cfg.MaxSizeMB * 1024 * 1024
+	for i := 0; i < len(files) && (len(files)-i > r.cfg.MaxFiles || totalSize > maxSizeBytes); i++ {
+		if err := os.Remove(filepath.Join(r.cfg.Dir, files[i].name)); err != nil {
+			log.Printf("crashreport: failed to evict %s: %v", files[i].name, err)
+			continue
+		}
+		totalSize -= files[i].size
+	}
+}
+
+// truncate cuts body down to max bytes, appending a marker so it's obvious
+// in a spooled report or Sentry extra that the body was cut short.
+func truncate(body string, max int) string {
+	if len(body) <= max {
+		return body
+	}
+	return body[:max] + "...[truncated]"
+}
+
+var piiPattern = regexp.MustCompile(`[\w.+-]+@[\w-]+\.[\w.-]+|\b\d{3}[-.\s]?\d{3}[-.\s]?\d{4}\b`)
+
+// redact scrubs common PII patterns (emails, US-style phone numbers) from a
+// message body before it's persisted or forwarded.
+func redact(body string) string {
+	return piiPattern.ReplaceAllString(body, "[REDACTED]")
+}
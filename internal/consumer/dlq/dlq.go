@@ -0,0 +1,364 @@
+// Package dlq drains a dead-letter queue on a schedule, classifying each
+// message as transient, retryable-with-backoff, or poison so a handful of
+// malformed messages can't silently wedge a queue forever.
+package dlq
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+
+	"sqs-fargate-consumer/internal/consumer"
+)
+
+// Classification is the outcome DLQConsumer assigns a dead-lettered message.
+type Classification string
+
+const (
+	// ClassTransient messages carry no error-type attribute, meaning SQS's
+	// own redrive policy moved them here rather than a worker giving up -
+	// they get one more shot on the main queue.
+	ClassTransient Classification = "transient"
+	// ClassRetryableBackoff messages failed for a reason that might clear
+	// up, so they're redriven after an exponential delay.
+	ClassRetryableBackoff Classification = "retryable-with-backoff"
+	// ClassPoison messages either failed for a reason that will never
+	// succeed or have exhausted their retries; they're archived instead of
+	// redriven.
+	ClassPoison Classification = "poison"
+)
+
+// Config configures a DLQConsumer.
+type Config struct {
+	// QueueName labels metrics and archive keys; it doesn't need to match
+	// the queue's SQS name.
+	QueueName string
+	// DLQURL is the dead-letter queue to drain.
+	DLQURL string
+	// MainQueueURL is where transient and backed-off messages are redriven.
+	MainQueueURL string
+	// ArchiveBucket is the S3 bucket poison messages are archived to.
+	ArchiveBucket string
+	// MaxRetries caps how many times a message may be redriven with
+	// backoff before it's classified as poison.
+	MaxRetries int
+	// PollInterval controls how often the queue is drained and its depth
+	// reported. Defaults to 30s.
+	PollInterval time.Duration
+}
+
+func (c *Config) setDefaults() {
+	if c.PollInterval <= 0 {
+		c.PollInterval = 30 * time.Second
+	}
+}
+
+// DLQConsumer drains Config.DLQURL, redriving or archiving each message it
+// finds.
+type DLQConsumer struct {
+	sqsClient *sqs.Client
+	s3Client  *s3.Client
+	collector *consumer.MetricsCollector
+	cfg       Config
+}
+
+func New(sqsClient *sqs.Client, s3Client *s3.Client, collector *consumer.MetricsCollector, cfg Config) *DLQConsumer {
+	cfg.setDefaults()
+	return &DLQConsumer{sqsClient: sqsClient, s3Client: s3Client, collector: collector, cfg: cfg}
+}
+
+// Run reports the DLQ's depth and drains it on cfg.PollInterval until ctx is
+// cancelled.
+func (d *DLQConsumer) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d.reportDepth(ctx)
+			if _, err := d.Drain(ctx, 10); err != nil {
+				log.Printf("dlq: scheduled drain failed: %v", err)
+			}
+		}
+	}
+}
+
+func (d *DLQConsumer) reportDepth(ctx context.Context) {
+	out, err := d.sqsClient.GetQueueAttributes(ctx, &sqs.GetQueueAttributesInput{
+		QueueUrl:       &d.cfg.DLQURL,
+		AttributeNames: []types.QueueAttributeName{types.QueueAttributeNameApproximateNumberOfMessages},
+	})
+	if err != nil {
+		log.Printf("dlq: failed to read queue depth: %v", err)
+		return
+	}
+
+	depthStr, ok := out.Attributes[string(types.QueueAttributeNameApproximateNumberOfMessages)]
+	if !ok {
+		return
+	}
+	if depth, err := strconv.Atoi(depthStr); err == nil {
+		d.collector.RecordDLQDepth(d.cfg.QueueName, depth)
+	}
+}
+
+// DrainResult summarizes the outcome of a Drain call.
+type DrainResult struct {
+	Redriven int
+	Archived int
+}
+
+// Drain receives up to max messages from the dead-letter queue, classifying
+// and handling each one, looping across SQS's own 10-message-per-call limit
+// as needed. It stops early once the queue runs dry.
+func (d *DLQConsumer) Drain(ctx context.Context, max int) (DrainResult, error) {
+	if max <= 0 {
+		max = 10
+	}
+
+	var result DrainResult
+	for result.Redriven+result.Archived < max {
+		batchSize := max - (result.Redriven + result.Archived)
+		if batchSize > 10 {
+			batchSize = 10
+		}
+
+		batch, received, err := d.drainBatch(ctx, batchSize)
+		if err != nil {
+			return result, err
+		}
+		result.Redriven += batch.Redriven
+		result.Archived += batch.Archived
+
+		if received == 0 {
+			break
+		}
+	}
+
+	return result, nil
+}
+
+func (d *DLQConsumer) drainBatch(ctx context.Context, size int) (DrainResult, int, error) {
+	out, err := d.sqsClient.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+		QueueUrl:                    &d.cfg.DLQURL,
+		MaxNumberOfMessages:         int32(size),
+		MessageAttributeNames:       []string{"All"},
+		MessageSystemAttributeNames: []types.MessageSystemAttributeName{types.MessageSystemAttributeNameApproximateReceiveCount},
+	})
+	if err != nil {
+		return DrainResult{}, 0, fmt.Errorf("receive from dlq: %w", err)
+	}
+
+	var result DrainResult
+	for _, msg := range out.Messages {
+		if err := d.handle(ctx, msg, &result); err != nil {
+			log.Printf("dlq: failed to handle message %s: %v", aws.ToString(msg.MessageId), err)
+		}
+	}
+	return result, len(out.Messages), nil
+}
+
+func (d *DLQConsumer) handle(ctx context.Context, msg types.Message, result *DrainResult) error {
+	class, attempt, errType := classify(msg, d.cfg.MaxRetries)
+
+	switch class {
+	case ClassTransient:
+		if err := d.redrive(ctx, msg, 0); err != nil {
+			return err
+		}
+		d.collector.RecordDLQRedriven(d.cfg.QueueName)
+		result.Redriven++
+
+	case ClassRetryableBackoff:
+		if err := d.redrive(ctx, msg, backoffDelay(attempt)); err != nil {
+			return err
+		}
+		d.collector.RecordDLQRedriven(d.cfg.QueueName)
+		result.Redriven++
+
+	case ClassPoison:
+		if err := d.archive(ctx, msg, errType, attempt); err != nil {
+			return err
+		}
+		d.collector.RecordDLQArchived(d.cfg.QueueName)
+		result.Archived++
+	}
+
+	_, err := d.sqsClient.DeleteMessage(ctx, &sqs.DeleteMessageInput{
+		QueueUrl:      &d.cfg.DLQURL,
+		ReceiptHandle: msg.ReceiptHandle,
+	})
+	return err
+}
+
+func (d *DLQConsumer) redrive(ctx context.Context, msg types.Message, delay time.Duration) error {
+	_, err := d.sqsClient.SendMessage(ctx, &sqs.SendMessageInput{
+		QueueUrl:          &d.cfg.MainQueueURL,
+		MessageBody:       msg.Body,
+		MessageAttributes: msg.MessageAttributes,
+		DelaySeconds:      int32(delay.Seconds()),
+	})
+	if err != nil {
+		return fmt.Errorf("redrive to main queue: %w", err)
+	}
+	return nil
+}
+
+// archivedMessage is the JSON document archive writes to S3 for a poison
+// message: the original payload plus enough of its error trail to debug
+// offline.
+type archivedMessage struct {
+	MessageID  string            `json:"message_id"`
+	Body       string            `json:"body"`
+	ErrorType  string            `json:"error_type"`
+	Attempt    int               `json:"attempt"`
+	Attributes map[string]string `json:"attributes"`
+	ArchivedAt time.Time         `json:"archived_at"`
+}
+
+func (d *DLQConsumer) archive(ctx context.Context, msg types.Message, errType string, attempt int) error {
+	archived := archivedMessage{
+		MessageID:  aws.ToString(msg.MessageId),
+		Body:       aws.ToString(msg.Body),
+		ErrorType:  errType,
+		Attempt:    attempt,
+		Attributes: msg.Attributes,
+		ArchivedAt: time.Now(),
+	}
+
+	body, err := json.Marshal(archived)
+	if err != nil {
+		return fmt.Errorf("marshal archived message: %w", err)
+	}
+
+	_, err = d.s3Client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: &d.cfg.ArchiveBucket,
+		Key:    aws.String(archived.MessageID + ".json"),
+		Body:   bytes.NewReader(body),
+	})
+	if err != nil {
+		return fmt.Errorf("archive to s3: %w", err)
+	}
+	return nil
+}
+
+// classify decides what to do with a message that has landed in the
+// dead-letter queue, based on the error-type and attempt-count attributes
+// consumer.Worker.handleError attaches when it gives up on a message (see
+// consumer.ErrorTypeAttribute).
+func classify(msg types.Message, maxRetries int) (class Classification, attempt int, errType string) {
+	attempt = attemptCount(msg)
+	errType = errorType(msg)
+
+	if errType == "" {
+		return ClassTransient, attempt, errType
+	}
+
+	if isPermanent(errType) {
+		return ClassPoison, attempt, errType
+	}
+
+	if maxRetries > 0 && attempt >= maxRetries {
+		return ClassPoison, attempt, errType
+	}
+
+	return ClassRetryableBackoff, attempt, errType
+}
+
+// isPermanent reports whether errType describes a failure that will never
+// succeed no matter how many times it's retried.
+func isPermanent(errType string) bool {
+	switch errType {
+	case "decode_error", "validation_error":
+		return true
+	default:
+		return false
+	}
+}
+
+func attemptCount(msg types.Message) int {
+	attr, ok := msg.MessageAttributes[consumer.AttemptCountAttribute]
+	if !ok || attr.StringValue == nil {
+		return 0
+	}
+	n, err := strconv.Atoi(*attr.StringValue)
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+func errorType(msg types.Message) string {
+	attr, ok := msg.MessageAttributes[consumer.ErrorTypeAttribute]
+	if !ok || attr.StringValue == nil {
+		return ""
+	}
+	return *attr.StringValue
+}
+
+const (
+	backoffBase = 30 * time.Second
+	backoffCap  = 15 * time.Minute
+)
+
+func backoffDelay(attempt int) time.Duration {
+	delay := backoffBase
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= backoffCap {
+			return backoffCap
+		}
+	}
+	return delay
+}
+
+// RedriveHandler returns an http.HandlerFunc suitable for mounting at
+// POST /admin/dlq/redrive?max=N to trigger an on-demand drain. If token is
+// non-empty, requests must carry a matching X-Admin-Token header.
+func (d *DLQConsumer) RedriveHandler(token string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if token != "" && r.Header.Get("X-Admin-Token") != token {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+
+		max := 10
+		if v := r.URL.Query().Get("max"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				http.Error(w, "invalid max", http.StatusBadRequest)
+				return
+			}
+			max = n
+		}
+
+		result, err := d.Drain(r.Context(), max)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(result); err != nil {
+			log.Printf("dlq: failed to write redrive response: %v", err)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package consumer
+
+import (
+	"context"
+)
+
+// Handler processes a single message. Implementations must be safe for
+// concurrent use: the same Handler is shared by every worker in the pool.
+type Handler interface {
+	Handle(ctx context.Context, msg Message) error
+}
+
+// HandlerFunc adapts a plain function to a Handler.
+type HandlerFunc func(ctx context.Context, msg Message) error
+
+// Handle calls f(ctx, msg).
+func (f HandlerFunc) Handle(ctx context.Context, msg Message) error {
+	return f(ctx, msg)
+}
+
+// Middleware wraps a Handler with cross-cutting behavior such as logging,
+// metric recording, or panic recovery.
+type Middleware func(Handler) Handler
+
+// ApplyDecorators wraps handler with each of mws in order, so mws[0] is the
+// outermost decorator and runs first.
+func ApplyDecorators(handler Handler, mws ...Middleware) Handler {
+	for i := len(mws) - 1; i >= 0; i-- {
+		handler = mws[i](handler)
+	}
+	return handler
+}
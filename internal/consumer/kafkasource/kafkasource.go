@@ -0,0 +1,166 @@
+// Package kafkasource implements consumer.Source over Kafka using
+// segmentio/kafka-go, with commit-on-ack semantics: Ack commits the
+// message's offset and Nack leaves it uncommitted, so the consumer group
+// redelivers it from the last committed offset on its next rebalance or
+// restart.
+package kafkasource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	kafka "github.com/segmentio/kafka-go"
+
+	"sqs-fargate-consumer/internal/consumer"
+)
+
+// pollTimeout bounds how long Receive waits for additional messages already
+// buffered on the reader once it has at least one, so a worker's batch
+// doesn't block indefinitely waiting to fill out max.
+const pollTimeout = 10 * time.Millisecond
+
+// Config configures a Source.
+type Config struct {
+	Brokers []string
+	Topic   string
+	// GroupID is the Kafka consumer group this Source joins; its committed
+	// offset is what Ack advances.
+	GroupID string
+	// MinBytes and MaxBytes bound a single fetch request to the broker.
+	// Default to 1 byte and 10MB when zero.
+	MinBytes int
+	MaxBytes int
+}
+
+func (c *Config) setDefaults() {
+	if c.MinBytes <= 0 {
+		c.MinBytes = 1
+	}
+	if c.MaxBytes <= 0 {
+		c.MaxBytes = 10e6
+	}
+}
+
+// Source reads a single Kafka topic through a consumer group. It implements
+// consumer.Source.
+type Source struct {
+	reader *kafka.Reader
+
+	mu       sync.Mutex
+	inFlight map[string]kafka.Message // keyed by Message.Receipt, awaiting Ack/Nack
+}
+
+// New creates a Source backed by a kafka.Reader for cfg.
+func New(cfg Config) *Source {
+	cfg.setDefaults()
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:  cfg.Brokers,
+		Topic:    cfg.Topic,
+		GroupID:  cfg.GroupID,
+		MinBytes: cfg.MinBytes,
+		MaxBytes: cfg.MaxBytes,
+	})
+	return &Source{reader: reader, inFlight: make(map[string]kafka.Message)}
+}
+
+// Close releases the underlying Kafka connection. Call it once during
+// shutdown, alongside Consumer.Shutdown.
+func (s *Source) Close() error {
+	return s.reader.Close()
+}
+
+// Receive blocks for the first message the way kafka.Reader.FetchMessage
+// does, then drains up to max-1 more that are already buffered, without
+// blocking further on each.
+func (s *Source) Receive(ctx context.Context, max int32) ([]consumer.Message, error) {
+	if max <= 0 {
+		max = 1
+	}
+
+	messages := make([]consumer.Message, 0, max)
+	for i := int32(0); i < max; i++ {
+		fetchCtx := ctx
+		if i > 0 {
+			var cancel context.CancelFunc
+			fetchCtx, cancel = context.WithTimeout(ctx, pollTimeout)
+			defer cancel()
+		}
+
+		m, err := s.reader.FetchMessage(fetchCtx)
+		if err != nil {
+			if i > 0 && errors.Is(err, context.DeadlineExceeded) {
+				break // nothing else buffered right now
+			}
+			return messages, fmt.Errorf("kafkasource: fetch: %w", err)
+		}
+
+		messages = append(messages, s.toMessage(m))
+	}
+
+	return messages, nil
+}
+
+// Ack commits msg's offset, advancing the consumer group past it.
+func (s *Source) Ack(ctx context.Context, msg consumer.Message) error {
+	m, ok := s.takeInFlight(msg.Receipt)
+	if !ok {
+		return fmt.Errorf("kafkasource: ack: unknown message %s", msg.Receipt)
+	}
+
+	if err := s.reader.CommitMessages(ctx, m); err != nil {
+		return fmt.Errorf("kafkasource: commit: %w", err)
+	}
+	return nil
+}
+
+// Nack leaves msg uncommitted so it's redelivered from the last committed
+// offset on the next rebalance or restart. Kafka has no per-message
+// visibility timeout to extend, so visibilityExtension is not honored; the
+// parameter exists only to satisfy consumer.Source.
+func (s *Source) Nack(ctx context.Context, msg consumer.Message, visibilityExtension time.Duration) error {
+	s.takeInFlight(msg.Receipt)
+	return nil
+}
+
+func (s *Source) takeInFlight(receipt string) (kafka.Message, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.inFlight[receipt]
+	if ok {
+		delete(s.inFlight, receipt)
+	}
+	return m, ok
+}
+
+// Stats maps kafka.Reader.Stats' consumer lag onto
+// SourceStats.ApproximateNumberOfMessages. Kafka has no equivalent to SQS's
+// not-visible count, so ApproximateNumberOfMessagesNotVisible is always
+// zero.
+func (s *Source) Stats() consumer.SourceStats {
+	stats := s.reader.Stats()
+	return consumer.SourceStats{ApproximateNumberOfMessages: int(stats.Lag)}
+}
+
+func (s *Source) toMessage(m kafka.Message) consumer.Message {
+	attrs := make(map[string]string, len(m.Headers))
+	for _, h := range m.Headers {
+		attrs[h.Key] = string(h.Value)
+	}
+
+	receipt := fmt.Sprintf("%d-%d", m.Partition, m.Offset)
+
+	s.mu.Lock()
+	s.inFlight[receipt] = m
+	s.mu.Unlock()
+
+	return consumer.Message{
+		ID:         receipt,
+		Body:       string(m.Value),
+		Attributes: attrs,
+		Receipt:    receipt,
+	}
+}
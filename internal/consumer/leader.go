@@ -0,0 +1,44 @@
+package consumer
+
+import "context"
+
+// LeaderElector decides which task in a multi-task deployment is allowed to
+// make scaling decisions. When multiple Fargate tasks run against the same
+// queue, each one evaluates the same queue depth independently; without
+// coordination every task scales up or down in lockstep and overshoots.
+// Exactly one task should observe IsLeader() == true at a time. Non-leader
+// tasks keep running their workers but must not call addWorker/removeWorker.
+type LeaderElector interface {
+	// Campaign blocks, repeatedly acquiring and renewing the lease until ctx
+	// is cancelled, at which point it resigns and returns ctx.Err().
+	Campaign(ctx context.Context) error
+
+	// IsLeader reports whether this task currently holds the lease.
+	IsLeader() bool
+
+	// OnLost registers a callback invoked whenever leadership is lost, e.g.
+	// after a failed lease renewal. May be called multiple times over the
+	// life of the elector if leadership is later regained and lost again.
+	OnLost(fn func())
+}
+
+// TargetBroadcaster is an optional capability a LeaderElector may implement
+// to let the leader's scaling decisions reach followers: the leader computes
+// a per-queue worker target same as if it were running standalone, then
+// publishes it for followers to converge toward instead of freezing at
+// whatever counts they had when leadership changed hands. A LeaderElector
+// that doesn't implement this (e.g. in single-task deployments with a nil
+// elector) simply leaves followers frozen, same as before.
+type TargetBroadcaster interface {
+	// PublishTargets persists targets (queue name -> desired worker count)
+	// for followers to read via Targets. Called only while the caller
+	// believes it's the leader; implementations may treat a concurrent loss
+	// of leadership as a harmless no-op, since the new leader will publish
+	// its own targets shortly.
+	PublishTargets(ctx context.Context, targets map[string]int) error
+
+	// Targets returns the most recently observed per-queue targets: this
+	// task's own, if it published them as leader, or the last values read
+	// from the elected leader, if following.
+	Targets() map[string]int
+}
@@ -0,0 +1,272 @@
+// Package leader provides LeaderElector implementations for
+// sqs-fargate-consumer's scaling coordination.
+package leader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Config configures a DynamoDB-backed lease, analogous to the Kubernetes
+// "leases" resource lock: a single item in TableName is the lease record,
+// and candidates race to write their Identity into it with an expiry.
+type Config struct {
+	// TableName is the DynamoDB table holding lease items. The table needs
+	// only a string partition key named "LeaseKey".
+	TableName string
+
+	// LeaseKey identifies the lease being contested, e.g. the queue name, so
+	// a single table can host leases for multiple queues/consumers.
+	LeaseKey string
+
+	// Identity identifies this candidate, e.g. the ECS task ARN or a
+	// hostname+uuid. Must be unique per running task.
+	Identity string
+
+	// LeaseTTL is how long a held lease remains valid without renewal.
+	// Defaults to 15s.
+	LeaseTTL time.Duration
+
+	// RenewInterval is how often the leader renews its lease and how often
+	// followers check for an open lease. Defaults to LeaseTTL/3.
+	RenewInterval time.Duration
+}
+
+func (c *Config) setDefaults() {
+	if c.LeaseTTL <= 0 {
+		c.LeaseTTL = 15 * time.Second
+	}
+	if c.RenewInterval <= 0 {
+		c.RenewInterval = c.LeaseTTL / 3
+	}
+}
+
+// DynamoDBElector implements consumer.LeaderElector on top of a single
+// DynamoDB item guarded by a conditional UpdateItem, the same compare-and-swap
+// pattern the Kubernetes leases resource lock uses.
+type DynamoDBElector struct {
+	client *dynamodb.Client
+	cfg    Config
+
+	isLeader atomic.Bool
+
+	// targets is this task's cached view of the lease item's Targets
+	// attribute, refreshed by PublishTargets (as leader) or refreshTargets
+	// (as follower, via Campaign's ticker).
+	targets atomic.Pointer[map[string]int]
+
+	mu      sync.Mutex
+	lostFns []func()
+}
+
+// New creates a DynamoDBElector. The caller is responsible for creating
+// TableName ahead of time with "LeaseKey" (string) as its partition key.
+func New(client *dynamodb.Client, cfg Config) *DynamoDBElector {
+	cfg.setDefaults()
+	return &DynamoDBElector{client: client, cfg: cfg}
+}
+
+// IsLeader reports whether this task currently holds the lease.
+func (e *DynamoDBElector) IsLeader() bool {
+	return e.isLeader.Load()
+}
+
+// OnLost registers fn to be called whenever leadership is lost.
+func (e *DynamoDBElector) OnLost(fn func()) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.lostFns = append(e.lostFns, fn)
+}
+
+func (e *DynamoDBElector) notifyLost() {
+	e.mu.Lock()
+	fns := make([]func(), len(e.lostFns))
+	copy(fns, e.lostFns)
+	e.mu.Unlock()
+
+	for _, fn := range fns {
+		fn()
+	}
+}
+
+// Campaign repeatedly attempts to acquire or renew the lease until ctx is
+// cancelled. On cancellation it resigns immediately (best effort) so that a
+// Fargate task replacement picks up leadership right away instead of
+// stalling scaling decisions for the remainder of the lease TTL.
+func (e *DynamoDBElector) Campaign(ctx context.Context) error {
+	ticker := time.NewTicker(e.cfg.RenewInterval)
+	defer ticker.Stop()
+
+	for {
+		acquired, err := e.tryAcquireOrRenew(ctx)
+		if err != nil {
+			log.Printf("leader: failed to acquire/renew lease %q: %v", e.cfg.LeaseKey, err)
+		}
+		if !acquired {
+			if e.isLeader.Swap(false) {
+				e.notifyLost()
+			}
+			e.refreshTargets(ctx)
+		}
+
+		select {
+		case <-ctx.Done():
+			e.resign()
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// tryAcquireOrRenew conditionally sets only HolderID/ExpiresAt, via
+// UpdateItem rather than PutItem, so that acquiring or renewing the lease
+// never clobbers a Targets attribute PublishTargets wrote - PutItem would
+// replace the whole item on every renewal, on its own RenewInterval ticker
+// independent of (and usually out of phase with) the Scaler's own tick that
+// calls PublishTargets.
+func (e *DynamoDBElector) tryAcquireOrRenew(ctx context.Context) (bool, error) {
+	now := time.Now()
+
+	_, err := e.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(e.cfg.TableName),
+		Key: map[string]types.AttributeValue{
+			"LeaseKey": &types.AttributeValueMemberS{Value: e.cfg.LeaseKey},
+		},
+		UpdateExpression:    aws.String("SET HolderID = :me, ExpiresAt = :expiresAt"),
+		ConditionExpression: aws.String("attribute_not_exists(LeaseKey) OR ExpiresAt < :now OR HolderID = :me"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":me":        &types.AttributeValueMemberS{Value: e.cfg.Identity},
+			":expiresAt": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", now.Add(e.cfg.LeaseTTL).Unix())},
+			":now":       &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", now.Unix())},
+		},
+	})
+	if err != nil {
+		var condErr *types.ConditionalCheckFailedException
+		if errors.As(err, &condErr) {
+			// Another task holds an unexpired lease; not an error, just not leader.
+			return false, nil
+		}
+		return false, err
+	}
+
+	e.isLeader.Store(true)
+	return true, nil
+}
+
+// resign releases the lease immediately if this task still holds it, so the
+// next campaigner doesn't have to wait out the full TTL. Failures are
+// swallowed: the lease will simply expire on its own.
+func (e *DynamoDBElector) resign() {
+	if !e.isLeader.Swap(false) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := e.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(e.cfg.TableName),
+		Key: map[string]types.AttributeValue{
+			"LeaseKey": &types.AttributeValueMemberS{Value: e.cfg.LeaseKey},
+		},
+		ConditionExpression: aws.String("HolderID = :me"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":me": &types.AttributeValueMemberS{Value: e.cfg.Identity},
+		},
+	})
+	if err != nil {
+		log.Printf("leader: failed to resign lease %q cleanly, will expire via TTL: %v", e.cfg.LeaseKey, err)
+	}
+}
+
+// PublishTargets writes targets into the lease item's Targets attribute,
+// conditioned on this task still holding the lease, so a concurrent loss of
+// leadership can't clobber the new leader's own targets with stale ones.
+// Implements consumer.TargetBroadcaster.
+func (e *DynamoDBElector) PublishTargets(ctx context.Context, targets map[string]int) error {
+	attr := make(map[string]types.AttributeValue, len(targets))
+	for name, n := range targets {
+		attr[name] = &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", n)}
+	}
+
+	_, err := e.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(e.cfg.TableName),
+		Key: map[string]types.AttributeValue{
+			"LeaseKey": &types.AttributeValueMemberS{Value: e.cfg.LeaseKey},
+		},
+		UpdateExpression:    aws.String("SET Targets = :targets"),
+		ConditionExpression: aws.String("HolderID = :me"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":targets": &types.AttributeValueMemberM{Value: attr},
+			":me":      &types.AttributeValueMemberS{Value: e.cfg.Identity},
+		},
+	})
+	if err != nil {
+		var condErr *types.ConditionalCheckFailedException
+		if errors.As(err, &condErr) {
+			// Lost the lease since computing targets; the new leader will
+			// publish its own shortly.
+			return nil
+		}
+		return fmt.Errorf("leader: publish targets: %w", err)
+	}
+
+	e.targets.Store(&targets)
+	return nil
+}
+
+// Targets returns the most recently observed per-queue targets. Implements
+// consumer.TargetBroadcaster.
+func (e *DynamoDBElector) Targets() map[string]int {
+	targets := e.targets.Load()
+	if targets == nil {
+		return nil
+	}
+	return *targets
+}
+
+// refreshTargets reads the lease item's Targets attribute so a follower's
+// Targets() reflects what the leader most recently published. Campaign
+// calls this on every tick it doesn't hold the lease.
+func (e *DynamoDBElector) refreshTargets(ctx context.Context) {
+	out, err := e.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(e.cfg.TableName),
+		Key: map[string]types.AttributeValue{
+			"LeaseKey": &types.AttributeValueMemberS{Value: e.cfg.LeaseKey},
+		},
+	})
+	if err != nil {
+		log.Printf("leader: failed to refresh targets for lease %q: %v", e.cfg.LeaseKey, err)
+		return
+	}
+
+	attr, ok := out.Item["Targets"].(*types.AttributeValueMemberM)
+	if !ok {
+		return
+	}
+
+	targets := make(map[string]int, len(attr.Value))
+	for name, v := range attr.Value {
+		n, ok := v.(*types.AttributeValueMemberN)
+		if !ok {
+			continue
+		}
+		count, err := strconv.Atoi(n.Value)
+		if err != nil {
+			continue
+		}
+		targets[name] = count
+	}
+
+	e.targets.Store(&targets)
+}
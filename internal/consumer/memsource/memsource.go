@@ -0,0 +1,124 @@
+// Package memsource implements consumer.Source as an in-memory FIFO queue,
+// for tests that exercise Consumer/Worker without a real broker.
+package memsource
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"sqs-fargate-consumer/internal/consumer"
+)
+
+// Source is an in-memory, in-process queue. Create one with New; the zero
+// value is not usable. Safe for concurrent use.
+type Source struct {
+	mu       sync.Mutex
+	queue    []consumer.Message
+	inFlight map[string]consumer.Message
+	next     int
+	notify   chan struct{} // closed and replaced on every Publish; Receive waits on it when empty
+}
+
+// New creates an empty Source.
+func New() *Source {
+	return &Source{inFlight: make(map[string]consumer.Message), notify: make(chan struct{})}
+}
+
+// Publish appends msg to the queue for a later Receive to pick up,
+// assigning it a Receipt if the caller left one unset.
+func (s *Source) Publish(msg consumer.Message) {
+	s.mu.Lock()
+	if msg.Receipt == "" {
+		msg.Receipt = fmt.Sprintf("%d", s.next)
+		s.next++
+	}
+	s.queue = append(s.queue, msg)
+	notify := s.notify
+	s.notify = make(chan struct{})
+	s.mu.Unlock()
+
+	close(notify)
+}
+
+// Receive returns up to max messages, blocking until at least one is queued
+// or ctx is done - mirroring sqssource's long poll closely enough that a
+// Worker polling a Source doesn't busy-loop at 100% CPU against an empty
+// memsource the way it would if Receive returned immediately.
+func (s *Source) Receive(ctx context.Context, max int32) ([]consumer.Message, error) {
+	if max <= 0 {
+		return nil, nil
+	}
+
+	for {
+		s.mu.Lock()
+		if len(s.queue) > 0 {
+			n := max
+			if int(n) > len(s.queue) {
+				n = int32(len(s.queue))
+			}
+			received := s.queue[:n]
+			s.queue = s.queue[n:]
+			for _, msg := range received {
+				s.inFlight[msg.Receipt] = msg
+			}
+			s.mu.Unlock()
+			return append([]consumer.Message(nil), received...), nil
+		}
+		notify := s.notify
+		s.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-notify:
+		}
+	}
+}
+
+// Ack removes msg from the queue for good.
+func (s *Source) Ack(ctx context.Context, msg consumer.Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.inFlight, msg.Receipt)
+	return nil
+}
+
+// Nack requeues msg for redelivery after visibilityExtension; a
+// non-positive duration requeues it immediately.
+func (s *Source) Nack(ctx context.Context, msg consumer.Message, visibilityExtension time.Duration) error {
+	s.mu.Lock()
+	delete(s.inFlight, msg.Receipt)
+	s.mu.Unlock()
+
+	if visibilityExtension <= 0 {
+		s.requeue(msg)
+		return nil
+	}
+
+	time.AfterFunc(visibilityExtension, func() { s.requeue(msg) })
+	return nil
+}
+
+func (s *Source) requeue(msg consumer.Message) {
+	s.mu.Lock()
+	s.queue = append(s.queue, msg)
+	notify := s.notify
+	s.notify = make(chan struct{})
+	s.mu.Unlock()
+
+	close(notify)
+}
+
+// Stats reports the number of queued and in-flight messages.
+func (s *Source) Stats() consumer.SourceStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return consumer.SourceStats{
+		ApproximateNumberOfMessages:           len(s.queue),
+		ApproximateNumberOfMessagesNotVisible: len(s.inFlight),
+	}
+}
@@ -2,31 +2,98 @@ package consumer
 
 import (
 	"context"
-	"log"
 	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.uber.org/zap"
+)
+
+// Metrics backend identifiers for Config.MetricsBackends.
+const (
+	BackendCloudWatch = "cloudwatch"
+	BackendPrometheus = "prometheus"
+	// BackendOTel publishes metrics as OpenTelemetry instruments, exported
+	// wherever internal/telemetry.Init pointed the global MeterProvider.
+	// With no OTLP endpoint configured, recording against these instruments
+	// is a no-op, so it's safe to always include this backend.
+	BackendOTel = "otel"
 )
 
 type MetricsCollector struct {
-	client       *cloudwatch.Client
+	client       *cloudwatch.Client // nil when the cloudwatch backend is disabled
+	backends     map[string]bool
 	metrics      chan Metric
-	metricValues map[string]float64
+	latest       map[string]Metric
 	metricWindow map[string][]MetricDataPoint
 	mu           sync.RWMutex
 	namespace    string
+	logger       *zap.Logger
+
+	// promRegistry is nil unless the prometheus backend is enabled.
+	promRegistry          *prometheus.Registry
+	promWorkerCount       *prometheus.GaugeVec
+	promWorkerUtilization *prometheus.GaugeVec
+	promQueueDepth        *prometheus.GaugeVec
+	promProcessingTime    *prometheus.HistogramVec
+	promErrors            *prometheus.CounterVec
+	promDLQDepth          *prometheus.GaugeVec
+	promDLQRedriven       *prometheus.CounterVec
+	promDLQArchived       *prometheus.CounterVec
+	promPanicCount        *prometheus.CounterVec
+	promArrivalRate       *prometheus.GaugeVec
+	promServiceTime       *prometheus.GaugeVec
+	promTargetWorkerCount *prometheus.GaugeVec
+	promMessagesDLQd      *prometheus.CounterVec
+	promReceiveCount      *prometheus.HistogramVec
+
+	// The otel instruments below are nil unless the otel backend is
+	// enabled. Unlike promRegistry, they're recorded through whatever the
+	// global otel MeterProvider happens to be (see internal/telemetry), so
+	// there's no registry handle to keep here.
+	otelWorkerCount       metric.Int64Gauge
+	otelWorkerUtilization metric.Float64Gauge
+	otelQueueDepth        metric.Int64Gauge
+	otelProcessingTime    metric.Float64Histogram
+	otelErrors            metric.Int64Counter
+	otelDLQDepth          metric.Int64Gauge
+	otelDLQRedriven       metric.Int64Counter
+	otelDLQArchived       metric.Int64Counter
+	otelPanicCount        metric.Int64Counter
+	otelArrivalRate       metric.Float64Gauge
+	otelServiceTime       metric.Float64Gauge
+	otelTargetWorkerCount metric.Int64Gauge
+	otelMessagesDLQd      metric.Int64Counter
+	otelReceiveCount      metric.Float64Histogram
 }
 
+// Metric is one sample recorded through RecordMetric. Queue, when set,
+// identifies which queue the sample belongs to: it becomes a CloudWatch
+// dimension and a Prometheus label so multi-queue deployments can be told
+// apart in both backends.
 type Metric struct {
 	Name      string
+	Queue     string
 	Value     float64
 	Unit      string
 	Timestamp time.Time
 }
 
+// metricKey returns the key used to store and look up a metric sample,
+// scoping it to a queue when one is given.
+func metricKey(name, queue string) string {
+	if queue == "" {
+		return name
+	}
+	return name + "@" + queue
+}
+
 type MetricDataPoint struct {
 	Value     float64
 	Timestamp time.Time
@@ -37,37 +104,209 @@ const (
 	MetricWorkerUtilization = "WorkerUtilization"
 	MetricQueueDepth        = "QueueDepth"
 	MetricProcessingTime    = "ProcessingTime"
+	MetricDLQDepth          = "DLQDepth"
+	MetricDLQRedriven       = "DLQRedriven"
+	MetricDLQArchived       = "DLQArchived"
+	MetricPanicCount        = "PanicCount"
+	MetricInFlightMessages  = "InFlightMessages"
+	MetricArrivalRate       = "ArrivalRate"
+	MetricServiceTime       = "ServiceTime"
+	MetricTargetWorkerCount = "TargetWorkerCount"
+	MetricMessagesDLQd      = "MessagesDLQd"
 )
 
-func NewMetricsCollector(client *cloudwatch.Client, namespace string) *MetricsCollector {
-	return &MetricsCollector{
+// NewMetricsCollector creates a MetricsCollector that publishes to the
+// backends named in config.MetricsBackends ("cloudwatch", "prometheus",
+// "otel"). When MetricsBackends is empty it defaults to ["cloudwatch"] to
+// match prior behavior.
+func NewMetricsCollector(client *cloudwatch.Client, namespace string, config *Config, logger *zap.Logger) *MetricsCollector {
+	c := &MetricsCollector{
 		client:       client,
+		backends:     backendSet(config.MetricsBackends),
 		metrics:      make(chan Metric, 1000),
-		metricValues: make(map[string]float64),
+		latest:       make(map[string]Metric),
 		metricWindow: make(map[string][]MetricDataPoint),
 		namespace:    namespace,
+		logger:       logger,
 	}
+
+	if c.backends[BackendPrometheus] {
+		buckets := config.ProcessingTimeBuckets
+		if len(buckets) == 0 {
+			buckets = prometheus.DefBuckets
+		}
+
+		c.promRegistry = prometheus.NewRegistry()
+		c.promWorkerCount = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "sqs_consumer_worker_count",
+			Help: "Number of workers currently running.",
+		}, []string{"queue"})
+		c.promWorkerUtilization = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "sqs_consumer_worker_utilization_percent",
+			Help: "Percentage of workers currently processing a message.",
+		}, []string{"queue"})
+		c.promQueueDepth = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "sqs_consumer_queue_depth",
+			Help: "Approximate number of visible messages in the queue.",
+		}, []string{"queue"})
+		c.promProcessingTime = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "sqs_consumer_processing_duration_seconds",
+			Help:    "Message handler processing duration in seconds.",
+			Buckets: buckets,
+		}, []string{"queue"})
+		c.promErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "sqs_consumer_errors_total",
+			Help: "Count of errors by type.",
+		}, []string{"error_type", "queue", "worker_id"})
+		c.promDLQDepth = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "sqs_consumer_dlq_depth",
+			Help: "Approximate number of messages sitting in the dead-letter queue.",
+		}, []string{"queue"})
+		c.promDLQRedriven = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "sqs_consumer_dlq_redriven_total",
+			Help: "Count of dead-lettered messages sent back to the main queue.",
+		}, []string{"queue"})
+		c.promDLQArchived = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "sqs_consumer_dlq_archived_total",
+			Help: "Count of dead-lettered messages classified as poison and archived.",
+		}, []string{"queue"})
+		c.promPanicCount = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "sqs_consumer_worker_panics_total",
+			Help: "Count of worker-goroutine panics recovered, by panic type.",
+		}, []string{"queue", "panic_type"})
+		c.promArrivalRate = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "sqs_consumer_arrival_rate",
+			Help: "EWMA of message arrival rate (messages/second) computed by the predictive scaler.",
+		}, []string{"queue"})
+		c.promServiceTime = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "sqs_consumer_service_time_seconds",
+			Help: "EWMA of per-message service time computed by the predictive scaler.",
+		}, []string{"queue"})
+		c.promTargetWorkerCount = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "sqs_consumer_target_worker_count",
+			Help: "Worker count the predictive scaler's Little's Law controller currently targets, before clamping and hysteresis.",
+		}, []string{"queue"})
+		c.promMessagesDLQd = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "messages_dlq_total",
+			Help: "Count of messages FailurePolicyExplicitDLQ moved directly to the dead-letter queue, bypassing the broker's own redrive policy.",
+		}, []string{"queue"})
+		c.promReceiveCount = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "receive_count_histogram",
+			Help:    "Distribution of how many times a message was received before it was acked or moved to the dead-letter queue.",
+			Buckets: []float64{1, 2, 3, 4, 5, 7, 10, 15, 20, 30},
+		}, []string{"queue"})
+
+		c.promRegistry.MustRegister(
+			c.promWorkerCount,
+			c.promWorkerUtilization,
+			c.promQueueDepth,
+			c.promProcessingTime,
+			c.promErrors,
+			c.promDLQDepth,
+			c.promDLQRedriven,
+			c.promDLQArchived,
+			c.promPanicCount,
+			c.promArrivalRate,
+			c.promServiceTime,
+			c.promTargetWorkerCount,
+			c.promMessagesDLQd,
+			c.promReceiveCount,
+		)
+	}
+
+	if c.backends[BackendOTel] {
+		meter := otel.Meter(namespace)
+
+		c.otelWorkerCount, _ = meter.Int64Gauge("sqs_consumer_worker_count",
+			metric.WithDescription("Number of workers currently running."))
+		c.otelWorkerUtilization, _ = meter.Float64Gauge("sqs_consumer_worker_utilization_percent",
+			metric.WithDescription("Percentage of workers currently processing a message."))
+		c.otelQueueDepth, _ = meter.Int64Gauge("sqs_consumer_queue_depth",
+			metric.WithDescription("Approximate number of visible messages in the queue."))
+		c.otelProcessingTime, _ = meter.Float64Histogram("sqs_consumer_processing_duration_seconds",
+			metric.WithDescription("Message handler processing duration in seconds."))
+		c.otelErrors, _ = meter.Int64Counter("sqs_consumer_errors_total",
+			metric.WithDescription("Count of errors by type."))
+		c.otelDLQDepth, _ = meter.Int64Gauge("sqs_consumer_dlq_depth",
+			metric.WithDescription("Approximate number of messages sitting in the dead-letter queue."))
+		c.otelDLQRedriven, _ = meter.Int64Counter("sqs_consumer_dlq_redriven_total",
+			metric.WithDescription("Count of dead-lettered messages sent back to the main queue."))
+		c.otelDLQArchived, _ = meter.Int64Counter("sqs_consumer_dlq_archived_total",
+			metric.WithDescription("Count of dead-lettered messages classified as poison and archived."))
+		c.otelPanicCount, _ = meter.Int64Counter("sqs_consumer_worker_panics_total",
+			metric.WithDescription("Count of worker-goroutine panics recovered, by panic type."))
+		c.otelArrivalRate, _ = meter.Float64Gauge("sqs_consumer_arrival_rate",
+			metric.WithDescription("EWMA of message arrival rate (messages/second) computed by the predictive scaler."))
+		c.otelServiceTime, _ = meter.Float64Gauge("sqs_consumer_service_time_seconds",
+			metric.WithDescription("EWMA of per-message service time computed by the predictive scaler."))
+		c.otelTargetWorkerCount, _ = meter.Int64Gauge("sqs_consumer_target_worker_count",
+			metric.WithDescription("Worker count the predictive scaler's Little's Law controller currently targets, before clamping and hysteresis."))
+		c.otelMessagesDLQd, _ = meter.Int64Counter("messages_dlq_total",
+			metric.WithDescription("Count of messages FailurePolicyExplicitDLQ moved directly to the dead-letter queue, bypassing the broker's own redrive policy."))
+		c.otelReceiveCount, _ = meter.Float64Histogram("receive_count_histogram",
+			metric.WithDescription("Distribution of how many times a message was received before it was acked or moved to the dead-letter queue."))
+	}
+
+	return c
 }
 
-// GetMetric returns the current value of a metric
-func (c *MetricsCollector) GetMetric(name string) float64 {
+func backendSet(backends []string) map[string]bool {
+	if len(backends) == 0 {
+		return map[string]bool{BackendCloudWatch: true}
+	}
+
+	set := make(map[string]bool, len(backends))
+	for _, b := range backends {
+		set[b] = true
+	}
+	return set
+}
+
+// Registry returns the Prometheus registry metrics are published to, or nil
+// if the prometheus backend isn't enabled.
+func (c *MetricsCollector) Registry() *prometheus.Registry {
+	return c.promRegistry
+}
+
+// GetMetric returns the current value of a metric, optionally scoped to a
+// queue. Pass "" for queue to read a metric that isn't queue-scoped.
+func (c *MetricsCollector) GetMetric(name, queue string) float64 {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	// Return the current value if it exists
-	if value, exists := c.metricValues[name]; exists {
-		return value
+	if m, exists := c.latest[metricKey(name, queue)]; exists {
+		return m.Value
 	}
 
 	return 0
 }
 
-// GetMetricAverage returns the average value over the specified duration
-func (c *MetricsCollector) GetMetricAverage(name string, duration time.Duration) float64 {
+// Window returns the sliding-window datapoints recorded for name (optionally
+// scoped to queue) within the last duration, oldest first. Backs
+// AdminServer's GET /admin/metrics/window.
+func (c *MetricsCollector) Window(name, queue string, duration time.Duration) []MetricDataPoint {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	cutoff := time.Now().Add(-duration)
+	dataPoints := c.metricWindow[metricKey(name, queue)]
+
+	window := make([]MetricDataPoint, 0, len(dataPoints))
+	for _, dp := range dataPoints {
+		if dp.Timestamp.After(cutoff) {
+			window = append(window, dp)
+		}
+	}
+	return window
+}
+
+// GetMetricAverage returns the average value over the specified duration,
+// optionally scoped to a queue.
+func (c *MetricsCollector) GetMetricAverage(name, queue string, duration time.Duration) float64 {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	dataPoints := c.metricWindow[name]
+	dataPoints := c.metricWindow[metricKey(name, queue)]
 	if len(dataPoints) == 0 {
 		return 0
 	}
@@ -90,10 +329,13 @@ func (c *MetricsCollector) GetMetricAverage(name string, duration time.Duration)
 	return sum / float64(count)
 }
 
-// RecordMetric records a new metric value
-func (c *MetricsCollector) RecordMetric(name string, value float64, unit string) {
+// RecordMetric records a new metric value. queue, when non-empty, scopes the
+// sample to a single queue (CloudWatch dimension, Prometheus label); pass ""
+// for metrics that aren't queue-specific.
+func (c *MetricsCollector) RecordMetric(name string, value float64, unit string, queue string) {
 	metric := Metric{
 		Name:      name,
+		Queue:     queue,
 		Value:     value,
 		Unit:      unit,
 		Timestamp: time.Now(),
@@ -107,8 +349,10 @@ func (c *MetricsCollector) processMetric(metric Metric) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	key := metricKey(metric.Name, metric.Queue)
+
 	// Update current value
-	c.metricValues[metric.Name] = metric.Value
+	c.latest[key] = metric
 
 	// Add to sliding window
 	dataPoint := MetricDataPoint{
@@ -117,15 +361,15 @@ func (c *MetricsCollector) processMetric(metric Metric) {
 	}
 
 	// Initialize slice if it doesn't exist
-	if _, exists := c.metricWindow[metric.Name]; !exists {
-		c.metricWindow[metric.Name] = make([]MetricDataPoint, 0)
+	if _, exists := c.metricWindow[key]; !exists {
+		c.metricWindow[key] = make([]MetricDataPoint, 0)
 	}
 
 	// Add new datapoint
-	c.metricWindow[metric.Name] = append(c.metricWindow[metric.Name], dataPoint)
+	c.metricWindow[key] = append(c.metricWindow[key], dataPoint)
 
 	// Cleanup old datapoints (keep last hour)
-	c.cleanupOldDataPoints(metric.Name)
+	c.cleanupOldDataPoints(key)
 }
 
 // cleanupOldDataPoints removes datapoints older than 30 minutes
@@ -148,7 +392,10 @@ func (c *MetricsCollector) cleanupOldDataPoints(metricName string) {
 	}
 }
 
-// Start begins processing metrics and publishing to CloudWatch
+// Start begins processing metrics and, if the cloudwatch backend is enabled,
+// publishing them to CloudWatch on an interval. The prometheus backend needs
+// no periodic publish step: its registry is scraped on demand via the
+// /metrics endpoint mounted by utils.StartHTTPSServer.
 func (c *MetricsCollector) Start(ctx context.Context) {
 	// Process incoming metrics
 	go func() {
@@ -162,6 +409,11 @@ func (c *MetricsCollector) Start(ctx context.Context) {
 		}
 	}()
 
+	if !c.backends[BackendCloudWatch] {
+		<-ctx.Done()
+		return
+	}
+
 	// Publish to CloudWatch periodically
 	ticker := time.NewTicker(10 * time.Second)
 	defer ticker.Stop()
@@ -179,15 +431,21 @@ func (c *MetricsCollector) Start(ctx context.Context) {
 // publishMetrics publishes all current metrics to CloudWatch
 func (c *MetricsCollector) publishMetrics() {
 	c.mu.RLock()
-	metricData := make([]types.MetricDatum, 0)
+	metricData := make([]types.MetricDatum, 0, len(c.latest))
 
-	for name, value := range c.metricValues {
-		metricData = append(metricData, types.MetricDatum{
-			MetricName: &name,
-			Value:      &value,
+	for _, m := range c.latest {
+		datum := types.MetricDatum{
+			MetricName: aws.String(m.Name),
+			Value:      aws.Float64(m.Value),
 			Timestamp:  aws.Time(time.Now()),
 			Unit:       types.StandardUnitCount, // Adjust based on metric type
-		})
+		}
+		if m.Queue != "" {
+			datum.Dimensions = []types.Dimension{
+				{Name: aws.String("QueueName"), Value: aws.String(m.Queue)},
+			}
+		}
+		metricData = append(metricData, datum)
 	}
 	c.mu.RUnlock()
 
@@ -197,27 +455,195 @@ func (c *MetricsCollector) publishMetrics() {
 			MetricData: metricData,
 		})
 		if err != nil {
-			log.Printf("Error publishing metrics to CloudWatch: %v", err)
+			c.logger.Error("failed to publish metrics to cloudwatch", zap.Error(err))
 		}
 	}
 }
 
-// RecordProcessingTime records message processing duration
-func (c *MetricsCollector) RecordProcessingTime(duration time.Duration) {
-	c.RecordMetric(MetricProcessingTime, float64(duration.Milliseconds()), "Milliseconds")
+// RecordProcessingTime records message processing duration for queue.
+func (c *MetricsCollector) RecordProcessingTime(queue string, duration time.Duration) {
+	c.RecordMetric(MetricProcessingTime, float64(duration.Milliseconds()), "Milliseconds", queue)
+
+	if c.promProcessingTime != nil {
+		c.promProcessingTime.WithLabelValues(queue).Observe(duration.Seconds())
+	}
+	if c.otelProcessingTime != nil {
+		c.otelProcessingTime.Record(context.Background(), duration.Seconds(), metric.WithAttributes(attribute.String("queue", queue)))
+	}
 }
 
-// RecordQueueDepth records current SQS queue depth
-func (c *MetricsCollector) RecordQueueDepth(depth int) {
-	c.RecordMetric(MetricQueueDepth, float64(depth), "Count")
+// RecordQueueDepth records current SQS queue depth for queue.
+func (c *MetricsCollector) RecordQueueDepth(queue string, depth int) {
+	c.RecordMetric(MetricQueueDepth, float64(depth), "Count", queue)
+
+	if c.promQueueDepth != nil {
+		c.promQueueDepth.WithLabelValues(queue).Set(float64(depth))
+	}
+	if c.otelQueueDepth != nil {
+		c.otelQueueDepth.Record(context.Background(), int64(depth), metric.WithAttributes(attribute.String("queue", queue)))
+	}
 }
 
-// RecordWorkerUtilization records worker utilization percentage
-func (c *MetricsCollector) RecordWorkerUtilization(utilizationPercentage float64) {
-	c.RecordMetric(MetricWorkerUtilization, utilizationPercentage, "Percent")
+// RecordWorkerUtilization records worker utilization percentage for queue.
+func (c *MetricsCollector) RecordWorkerUtilization(queue string, utilizationPercentage float64) {
+	c.RecordMetric(MetricWorkerUtilization, utilizationPercentage, "Percent", queue)
+
+	if c.promWorkerUtilization != nil {
+		c.promWorkerUtilization.WithLabelValues(queue).Set(utilizationPercentage)
+	}
+	if c.otelWorkerUtilization != nil {
+		c.otelWorkerUtilization.Record(context.Background(), utilizationPercentage, metric.WithAttributes(attribute.String("queue", queue)))
+	}
 }
 
-// RecordError records an error occurrence
-func (c *MetricsCollector) RecordError(errorType string) {
-	c.RecordMetric("Error_"+errorType, 1, "Count")
+// RecordWorkerCount records the current size of queue's worker pool.
+func (c *MetricsCollector) RecordWorkerCount(queue string, count int) {
+	c.RecordMetric(MetricWorkerCount, float64(count), "Count", queue)
+
+	if c.promWorkerCount != nil {
+		c.promWorkerCount.WithLabelValues(queue).Set(float64(count))
+	}
+	if c.otelWorkerCount != nil {
+		c.otelWorkerCount.Record(context.Background(), int64(count), metric.WithAttributes(attribute.String("queue", queue)))
+	}
+}
+
+// RecordDLQDepth records the approximate number of messages sitting in
+// queue's dead-letter queue, so the scaler (or an operator) can see poison
+// buildup before it becomes a problem.
+func (c *MetricsCollector) RecordDLQDepth(queue string, depth int) {
+	c.RecordMetric(MetricDLQDepth, float64(depth), "Count", queue)
+
+	if c.promDLQDepth != nil {
+		c.promDLQDepth.WithLabelValues(queue).Set(float64(depth))
+	}
+	if c.otelDLQDepth != nil {
+		c.otelDLQDepth.Record(context.Background(), int64(depth), metric.WithAttributes(attribute.String("queue", queue)))
+	}
+}
+
+// RecordDLQRedriven counts a dead-lettered message the DLQ consumer sent
+// back to the main queue for another attempt.
+func (c *MetricsCollector) RecordDLQRedriven(queue string) {
+	c.RecordMetric(MetricDLQRedriven, 1, "Count", queue)
+
+	if c.promDLQRedriven != nil {
+		c.promDLQRedriven.WithLabelValues(queue).Inc()
+	}
+	if c.otelDLQRedriven != nil {
+		c.otelDLQRedriven.Add(context.Background(), 1, metric.WithAttributes(attribute.String("queue", queue)))
+	}
+}
+
+// RecordDLQArchived counts a dead-lettered message the DLQ consumer
+// classified as poison and archived instead of retrying.
+func (c *MetricsCollector) RecordDLQArchived(queue string) {
+	c.RecordMetric(MetricDLQArchived, 1, "Count", queue)
+
+	if c.promDLQArchived != nil {
+		c.promDLQArchived.WithLabelValues(queue).Inc()
+	}
+	if c.otelDLQArchived != nil {
+		c.otelDLQArchived.Add(context.Background(), 1, metric.WithAttributes(attribute.String("queue", queue)))
+	}
+}
+
+// RecordPanicCount counts a worker-goroutine panic of panicType on queue, so
+// the scaler (or an operator) can alarm when a specific message keeps
+// crashing workers. See Consumer.runWorker.
+func (c *MetricsCollector) RecordPanicCount(queue, panicType string) {
+	c.RecordMetric(MetricPanicCount, 1, "Count", queue)
+
+	if c.promPanicCount != nil {
+		c.promPanicCount.WithLabelValues(queue, panicType).Inc()
+	}
+	if c.otelPanicCount != nil {
+		c.otelPanicCount.Add(context.Background(), 1, metric.WithAttributes(attribute.String("queue", queue), attribute.String("panic_type", panicType)))
+	}
+}
+
+// RecordArrivalRate records the predictive scaler's current EWMA estimate of
+// queue's message arrival rate, in messages per second.
+func (c *MetricsCollector) RecordArrivalRate(queue string, rate float64) {
+	c.RecordMetric(MetricArrivalRate, rate, "CountPerSecond", queue)
+
+	if c.promArrivalRate != nil {
+		c.promArrivalRate.WithLabelValues(queue).Set(rate)
+	}
+	if c.otelArrivalRate != nil {
+		c.otelArrivalRate.Record(context.Background(), rate, metric.WithAttributes(attribute.String("queue", queue)))
+	}
+}
+
+// RecordServiceTime records the predictive scaler's current EWMA estimate of
+// queue's per-message service time, in seconds.
+func (c *MetricsCollector) RecordServiceTime(queue string, seconds float64) {
+	c.RecordMetric(MetricServiceTime, seconds, "Seconds", queue)
+
+	if c.promServiceTime != nil {
+		c.promServiceTime.WithLabelValues(queue).Set(seconds)
+	}
+	if c.otelServiceTime != nil {
+		c.otelServiceTime.Record(context.Background(), seconds, metric.WithAttributes(attribute.String("queue", queue)))
+	}
+}
+
+// RecordTargetWorkerCount records the worker count the predictive scaler's
+// Little's Law controller computed for queue before clamping to
+// MinWorkers/MaxWorkers and applying hysteresis, so operators can see how
+// close the controller is to acting.
+func (c *MetricsCollector) RecordTargetWorkerCount(queue string, target int) {
+	c.RecordMetric(MetricTargetWorkerCount, float64(target), "Count", queue)
+
+	if c.promTargetWorkerCount != nil {
+		c.promTargetWorkerCount.WithLabelValues(queue).Set(float64(target))
+	}
+	if c.otelTargetWorkerCount != nil {
+		c.otelTargetWorkerCount.Record(context.Background(), int64(target), metric.WithAttributes(attribute.String("queue", queue)))
+	}
+}
+
+// RecordMessageDLQd counts a message FailurePolicyExplicitDLQ moved
+// directly to queue's dead-letter queue, bypassing the broker's own redrive
+// policy.
+func (c *MetricsCollector) RecordMessageDLQd(queue string) {
+	c.RecordMetric(MetricMessagesDLQd, 1, "Count", queue)
+
+	if c.promMessagesDLQd != nil {
+		c.promMessagesDLQd.WithLabelValues(queue).Inc()
+	}
+	if c.otelMessagesDLQd != nil {
+		c.otelMessagesDLQd.Add(context.Background(), 1, metric.WithAttributes(attribute.String("queue", queue)))
+	}
+}
+
+// RecordReceiveCount records how many times a message on queue had been
+// received by the time it was acked or moved to the dead-letter queue.
+func (c *MetricsCollector) RecordReceiveCount(queue string, count int) {
+	if c.promReceiveCount != nil {
+		c.promReceiveCount.WithLabelValues(queue).Observe(float64(count))
+	}
+	if c.otelReceiveCount != nil {
+		c.otelReceiveCount.Record(context.Background(), float64(count), metric.WithAttributes(attribute.String("queue", queue)))
+	}
+}
+
+// RecordError records an error occurrence. labels may carry "queue" and/or
+// "worker_id" to distinguish where the error came from. On CloudWatch the
+// metric is named "Error_"+errorType, "queue" becomes its QueueName
+// dimension, and "worker_id" is not recorded; on Prometheus and otel both
+// become labels on the errors_total counter. labels may be nil.
+func (c *MetricsCollector) RecordError(errorType string, labels map[string]string) {
+	c.RecordMetric("Error_"+errorType, 1, "Count", labels["queue"])
+
+	if c.promErrors != nil {
+		c.promErrors.WithLabelValues(errorType, labels["queue"], labels["worker_id"]).Inc()
+	}
+	if c.otelErrors != nil {
+		c.otelErrors.Add(context.Background(), 1, metric.WithAttributes(
+			attribute.String("error_type", errorType),
+			attribute.String("queue", labels["queue"]),
+			attribute.String("worker_id", labels["worker_id"]),
+		))
+	}
 }
@@ -0,0 +1,99 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// LoggingMiddleware logs the outcome and duration of every handled message
+// through logger.
+func LoggingMiddleware(logger *zap.Logger) Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, msg Message) error {
+			start := time.Now()
+			err := next.Handle(ctx, msg)
+			if err != nil {
+				logger.Warn("handler failed", zap.String("message_id", msg.ID), zap.Duration("duration", time.Since(start)), zap.Error(err))
+			} else {
+				logger.Info("handler succeeded", zap.String("message_id", msg.ID), zap.Duration("duration", time.Since(start)))
+			}
+			return err
+		})
+	}
+}
+
+// MetricsMiddleware records processing time and error counts through
+// collector for every handled message.
+func MetricsMiddleware(collector *MetricsCollector) Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, msg Message) error {
+			start := time.Now()
+			err := next.Handle(ctx, msg)
+			collector.RecordProcessingTime("", time.Since(start))
+			if err != nil {
+				collector.RecordError("handler_error", nil)
+			}
+			return err
+		})
+	}
+}
+
+// RetryMiddleware re-invokes next up to n additional times when it returns
+// an error, sleeping backoff between attempts. It gives up early if ctx is
+// done.
+func RetryMiddleware(n int, backoff time.Duration) Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, msg Message) error {
+			var err error
+			for attempt := 0; attempt <= n; attempt++ {
+				if err = next.Handle(ctx, msg); err == nil {
+					return nil
+				}
+				if attempt == n {
+					break
+				}
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-time.After(backoff):
+				}
+			}
+			return fmt.Errorf("handler failed after %d attempts: %w", n+1, err)
+		})
+	}
+}
+
+// RecoverMiddleware converts a panic raised by next into an error so a
+// single malformed message cannot take down the worker goroutine processing
+// it.
+func RecoverMiddleware(collector *MetricsCollector) Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, msg Message) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					collector.RecordError("handler_panic", nil)
+					err = fmt.Errorf("handler panicked: %v", r)
+				}
+			}()
+			return next.Handle(ctx, msg)
+		})
+	}
+}
+
+// DecodeJSON adapts fn, which expects a typed payload, into a Handler by
+// unmarshalling msg.Body as JSON into a T before calling it.
+func DecodeJSON[T any](fn func(ctx context.Context, msg Message, payload T) error) Handler {
+	return HandlerFunc(func(ctx context.Context, msg Message) error {
+		var payload T
+		if msg.Body != "" {
+			if err := json.Unmarshal([]byte(msg.Body), &payload); err != nil {
+				return fmt.Errorf("decode json payload: %w", err)
+			}
+		}
+		return fn(ctx, msg, payload)
+	})
+}
@@ -0,0 +1,56 @@
+package consumer
+
+import "sync"
+
+// QueuePool owns the workers polling a single queue, along with the
+// worker-count bounds and scaling priority Scaler uses to size it.
+type QueuePool struct {
+	config  QueueConfig
+	workers map[string]*Worker
+	mu      sync.RWMutex
+}
+
+func newQueuePool(config QueueConfig) *QueuePool {
+	return &QueuePool{
+		config:  config,
+		workers: make(map[string]*Worker),
+	}
+}
+
+func (p *QueuePool) workerCount() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return len(p.workers)
+}
+
+func (p *QueuePool) activeWorkerCount() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	active := 0
+	for _, w := range p.workers {
+		if w.isProcessing.Load() {
+			active++
+		}
+	}
+	return active
+}
+
+// workerStatuses snapshots every worker in the pool for AdminServer's
+// GET /admin/workers.
+func (p *QueuePool) workerStatuses(queueName string) []workerStatus {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	statuses := make([]workerStatus, 0, len(p.workers))
+	for id, w := range p.workers {
+		statuses = append(statuses, workerStatus{
+			Queue:          queueName,
+			WorkerID:       id,
+			IsProcessing:   w.isProcessing.Load(),
+			ProcessedCount: w.processedCount.Load(),
+			LastMessageAt:  w.LastMessageAt(),
+		})
+	}
+	return statuses
+}
@@ -0,0 +1,83 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// EventTypeAttribute is the message attribute Router checks to determine
+// which handler to dispatch a message to.
+const EventTypeAttribute = "event_type"
+
+// snsEnvelope is the subset of an SNS notification's JSON body Router needs
+// to recover an event type when the queue is subscribed to an SNS topic
+// without raw message delivery enabled, in which case the original message
+// attributes travel inside the body rather than as the Source's own message
+// attributes.
+type snsEnvelope struct {
+	Type              string `json:"Type"`
+	MessageAttributes map[string]struct {
+		Type  string `json:"Type"`
+		Value string `json:"Value"`
+	} `json:"MessageAttributes"`
+}
+
+// Router dispatches a message to the Handler registered for its event type.
+// The event type is read from the "event_type" message attribute or,
+// failing that, from an SNS-wrapped payload's own message attributes.
+type Router struct {
+	handlers map[string]Handler
+	fallback Handler
+}
+
+// NewRouter creates an empty Router. Register handlers with Handle and,
+// optionally, a catch-all with Fallback.
+func NewRouter() *Router {
+	return &Router{handlers: make(map[string]Handler)}
+}
+
+// Handle registers h to process messages whose event type is eventType.
+func (r *Router) Handle(eventType string, h Handler) {
+	r.handlers[eventType] = h
+}
+
+// Fallback registers h to process messages whose event type matches no
+// registered handler, or that carry no event type at all.
+func (r *Router) Fallback(h Handler) {
+	r.fallback = h
+}
+
+// Route dispatches msg to the handler registered for its event type. Its
+// signature matches HandlerFunc, so HandlerFunc(r.Route) can be wrapped with
+// Middleware like any other handler.
+func (r *Router) Route(ctx context.Context, msg Message) error {
+	eventType := r.eventType(msg)
+
+	if h, ok := r.handlers[eventType]; ok {
+		return h.Handle(ctx, msg)
+	}
+	if r.fallback != nil {
+		return r.fallback.Handle(ctx, msg)
+	}
+	return fmt.Errorf("router: no handler registered for event type %q", eventType)
+}
+
+func (r *Router) eventType(msg Message) string {
+	if v, ok := msg.Attributes[EventTypeAttribute]; ok {
+		return v
+	}
+
+	if msg.Body == "" {
+		return ""
+	}
+
+	var envelope snsEnvelope
+	if err := json.Unmarshal([]byte(msg.Body), &envelope); err != nil || envelope.Type == "" {
+		return ""
+	}
+	if attr, ok := envelope.MessageAttributes[EventTypeAttribute]; ok {
+		return attr.Value
+	}
+	return ""
+}
@@ -2,27 +2,99 @@ package consumer
 
 import (
 	"context"
-	"log"
+	"math"
+	"sync/atomic"
 	"time"
+
+	"go.uber.org/zap"
+)
+
+// Scaling mode identifiers for Config.ScalingMode.
+const (
+	// ScalingModePredictive sizes each queue's pool from an EWMA of arrival
+	// rate and service time via Little's Law. It's the default.
+	ScalingModePredictive = "predictive"
+	// ScalingModeThreshold falls back to the older reactive behavior: scale
+	// up when queue depth/utilization/processing time cross
+	// QueueConfig.ScaleUpThreshold, scale down when they fall below
+	// ScaleDownThreshold.
+	ScalingModeThreshold = "threshold"
+)
+
+// Defaults for the predictive scaling knobs in Config, applied when the
+// corresponding field is left zero.
+const (
+	defaultEWMAAlpha         = 0.3
+	defaultTargetUtilization = 0.7
+	defaultScalingDeadband   = 0.15
 )
 
 type Scaler struct {
-	collector      *MetricsCollector
-	consumer       *Consumer
-	config         *Config
-	lastScaleEvent time.Time
+	collector *MetricsCollector
+	consumer  *Consumer
+	config    *Config
+	elector   LeaderElector
+	logger    *zap.Logger
+
+	// lastScaleEvent is an atomic unix-nano timestamp rather than a plain
+	// time.Time: AdminServer's POST /admin/scale resets it from an HTTP
+	// handler goroutine, concurrently with evaluateScaling reading it from
+	// the ticker loop below.
+	lastScaleEvent atomic.Int64
+
+	// predictive holds the per-queue EWMA state evaluateScalingPredictive
+	// maintains between ticks, keyed by QueueConfig.Name. Only ever touched
+	// from the Start ticker loop, so it needs no locking of its own.
+	predictive map[string]*predictiveState
 }
 
-func NewScaler(collector *MetricsCollector, consumer *Consumer, config *Config) *Scaler {
-	return &Scaler{
-		collector:      collector,
-		consumer:       consumer,
-		config:         config,
-		lastScaleEvent: time.Now(),
+// predictiveState is one queue's running estimate of arrival rate and
+// service time, plus enough bookkeeping to debounce scaling decisions per
+// direction.
+type predictiveState struct {
+	initialized    bool
+	lastSampleTime time.Time
+	lastDepth      float64
+
+	lambda      float64 // EWMA arrival rate, messages/second
+	serviceTime float64 // EWMA per-message service time, seconds
+
+	lastUpScale   time.Time
+	lastDownScale time.Time
+}
+
+// NewScaler creates a Scaler. elector may be nil, in which case the scaler
+// always behaves as leader (single-task deployments don't need election).
+func NewScaler(collector *MetricsCollector, consumer *Consumer, config *Config, elector LeaderElector, logger *zap.Logger) *Scaler {
+	s := &Scaler{
+		collector:  collector,
+		consumer:   consumer,
+		config:     config,
+		elector:    elector,
+		logger:     logger,
+		predictive: make(map[string]*predictiveState),
 	}
+	s.lastScaleEvent.Store(time.Now().UnixNano())
+	return s
+}
+
+// ResetCooldown restarts the cooldown period, as if a scaling decision had
+// just been made. AdminServer's manual POST
+// /admin/scale override calls this so the scaler doesn't immediately try to
+// scale the queue back down right after an operator scales it up.
+func (s *Scaler) ResetCooldown() {
+	s.lastScaleEvent.Store(time.Now().UnixNano())
 }
 
 func (s *Scaler) Start(ctx context.Context) {
+	if s.elector != nil {
+		go func() {
+			if err := s.elector.Campaign(ctx); err != nil && ctx.Err() == nil {
+				s.logger.Error("leader election campaign exited", zap.Error(err))
+			}
+		}()
+	}
+
 	ticker := time.NewTicker(5 * time.Second)
 	defer ticker.Stop()
 
@@ -36,39 +108,363 @@ func (s *Scaler) Start(ctx context.Context) {
 	}
 }
 
+// evaluateScaling dispatches to the configured scaling mode. Only the
+// elected leader makes scaling decisions; followers don't evaluate metrics
+// of their own (every task sees the same queue depth, so that would just
+// have them race the leader to add/remove workers for the same queue) and
+// instead converge toward whatever targets the leader last published.
 func (s *Scaler) evaluateScaling() {
-	queueDepth := s.collector.GetMetric(MetricQueueDepth)
-	processingTime := s.collector.GetMetricAverage(MetricProcessingTime, 1*time.Minute)
-	workerUtilization := s.collector.GetMetricAverage(MetricWorkerUtilization, 1*time.Minute)
+	if s.elector != nil && !s.elector.IsLeader() {
+		s.followLeaderTargets()
+		return
+	}
+
+	if s.scalingMode() == ScalingModeThreshold {
+		s.evaluateScalingThreshold()
+	} else {
+		s.evaluateScalingPredictive()
+	}
+
+	s.publishTargets()
+}
+
+// publishTargets persists this task's current per-queue worker counts for
+// followers to converge toward, if the configured LeaderElector supports it.
+// evaluateScaling only calls this once it's established the task is leader.
+func (s *Scaler) publishTargets() {
+	broadcaster, ok := s.elector.(TargetBroadcaster)
+	if !ok {
+		return
+	}
+
+	targets := make(map[string]int, len(s.consumer.pools))
+	for name, pool := range s.consumer.pools {
+		targets[name] = pool.workerCount()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := broadcaster.PublishTargets(ctx, targets); err != nil {
+		s.logger.Error("failed to publish scaling targets", zap.Error(err))
+	}
+}
+
+// followLeaderTargets moves each queue's worker count one step toward the
+// leader's last-published target, if the configured LeaderElector supports
+// TargetBroadcaster. Without that support (or with no elector's targets
+// published yet), followers simply keep running their existing workers, as
+// before.
+func (s *Scaler) followLeaderTargets() {
+	broadcaster, ok := s.elector.(TargetBroadcaster)
+	if !ok {
+		return
+	}
 
-	if s.shouldScaleUp(queueDepth, processingTime, workerUtilization) {
-		err := s.consumer.addWorker()
-		if err != nil {
-			log.Printf("Failed to scale up: %v", err)
+	for name, target := range broadcaster.Targets() {
+		pool, ok := s.consumer.pools[name]
+		if !ok {
+			continue
 		}
-		s.lastScaleEvent = time.Now()
+		target = clampWorkers(target, pool.config)
+
+		switch current := pool.workerCount(); {
+		case current < target:
+			if err := s.consumer.addWorker(name); err != nil {
+				s.logger.Error("failed to follow scale-up target", zap.String("queue", name), zap.Error(err))
+			}
+		case current > target:
+			if err := s.consumer.removeWorker(name); err != nil {
+				s.logger.Error("failed to follow scale-down target", zap.String("queue", name), zap.Error(err))
+			}
+		}
+	}
+}
+
+// evaluateScalingPredictive updates each queue's arrival-rate and
+// service-time EWMAs, derives the worker count Little's Law calls for, and
+// applies it (subject to clamping, a deadband, and a per-direction cooldown)
+// independently per queue.
+func (s *Scaler) evaluateScalingPredictive() {
+	for _, pool := range s.consumer.pools {
+		qc := pool.config
+		state := s.predictiveStateFor(qc.Name)
+
+		if !s.sampleArrivalAndService(state, qc) {
+			continue // first tick for this queue; no prior depth to diff against yet
+		}
+
+		target := clampWorkers(s.littlesLawTarget(state.lambda, state.serviceTime), qc)
+
+		s.collector.RecordArrivalRate(qc.Name, state.lambda)
+		s.collector.RecordServiceTime(qc.Name, state.serviceTime)
+		s.collector.RecordTargetWorkerCount(qc.Name, target)
+
+		s.applyPredictiveTarget(pool, state, target)
+	}
+}
+
+// sampleArrivalAndService folds the latest queue-depth and processing-time
+// samples into state's EWMAs and reports whether state now has a baseline
+// to compare against. It returns false on a queue's very first call, when
+// there's no previous depth sample to diff against yet.
+func (s *Scaler) sampleArrivalAndService(state *predictiveState, qc QueueConfig) bool {
+	now := time.Now()
+	depth := s.collector.GetMetric(MetricQueueDepth, qc.Name)
+
+	if !state.initialized {
+		state.initialized = true
+		state.lastSampleTime = now
+		state.lastDepth = depth
+		return false
+	}
+
+	elapsed := now.Sub(state.lastSampleTime)
+	state.lastSampleTime = now
+	defer func() { state.lastDepth = depth }()
+	if elapsed <= 0 {
+		return false
+	}
+
+	// Messages processed since the last sample show up as ProcessingTime
+	// datapoints recorded in that window; each one is a completed message.
+	processed := s.collector.Window(MetricProcessingTime, qc.Name, elapsed)
+
+	alpha := s.alpha()
+
+	arrived := depth - state.lastDepth + float64(len(processed))
+	rawLambda := arrived / elapsed.Seconds()
+	if rawLambda < 0 {
+		rawLambda = 0
+	}
+	state.lambda = alpha*rawLambda + (1-alpha)*state.lambda
+
+	if len(processed) > 0 {
+		var sumMillis float64
+		for _, dp := range processed {
+			sumMillis += dp.Value
+		}
+		sampleServiceTime := (sumMillis / float64(len(processed))) / 1000
+		state.serviceTime = alpha*sampleServiceTime + (1-alpha)*state.serviceTime
+	}
+
+	return true
+}
+
+// littlesLawTarget derives the worker count Little's Law calls for: the
+// number of concurrent workers needed to keep up with lambda messages/second
+// arriving, each taking serviceTime seconds to process, while keeping
+// average worker utilization at targetUtilization.
+func (s *Scaler) littlesLawTarget(lambda, serviceTime float64) int {
+	if lambda <= 0 || serviceTime <= 0 {
+		return 0
+	}
+	return int(math.Ceil(lambda * serviceTime / s.targetUtilization()))
+}
+
+// clampWorkers bounds n to qc's MinWorkers/MaxWorkers.
+func clampWorkers(n int, qc QueueConfig) int {
+	if n < qc.MinWorkers {
+		n = qc.MinWorkers
+	}
+	if qc.MaxWorkers > 0 && n > qc.MaxWorkers {
+		n = qc.MaxWorkers
+	}
+	return n
+}
+
+// applyPredictiveTarget moves pool one worker toward target if the gap
+// exceeds the configured deadband and the cooldown for that direction has
+// elapsed, mirroring the reactive path's "at most one change per tick" and
+// cooldown behavior, but decided per queue instead of globally.
+func (s *Scaler) applyPredictiveTarget(pool *QueuePool, state *predictiveState, target int) {
+	current := pool.workerCount()
+
+	if current > 0 {
+		if math.Abs(float64(target-current)/float64(current)) < s.deadband() {
+			return
+		}
+	} else if target <= 0 {
+		return
+	}
+
+	now := time.Now()
+	// A manual POST /admin/scale override (see ResetCooldown) holds off both
+	// directions for CooldownPeriod.
+	if now.Sub(time.Unix(0, s.lastScaleEvent.Load())) < s.config.CooldownPeriod {
+		return
+	}
+
+	switch {
+	case target > current:
+		if now.Sub(state.lastUpScale) < s.config.CooldownPeriod {
+			return
+		}
+		if err := s.consumer.addWorker(pool.config.Name); err != nil {
+			s.logger.Error("failed to scale up queue", zap.String("queue", pool.config.Name), zap.Error(err))
+			return
+		}
+		state.lastUpScale = now
+	case target < current:
+		if now.Sub(state.lastDownScale) < s.config.CooldownPeriod {
+			return
+		}
+		if err := s.consumer.removeWorker(pool.config.Name); err != nil {
+			s.logger.Error("failed to scale down queue", zap.String("queue", pool.config.Name), zap.Error(err))
+			return
+		}
+		state.lastDownScale = now
+	}
+}
+
+func (s *Scaler) predictiveStateFor(queueName string) *predictiveState {
+	st, ok := s.predictive[queueName]
+	if !ok {
+		st = &predictiveState{}
+		s.predictive[queueName] = st
+	}
+	return st
+}
+
+func (s *Scaler) scalingMode() string {
+	if s.config.ScalingMode != "" {
+		return s.config.ScalingMode
+	}
+	return ScalingModePredictive
+}
+
+func (s *Scaler) alpha() float64 {
+	if s.config.EWMAAlpha > 0 {
+		return s.config.EWMAAlpha
+	}
+	return defaultEWMAAlpha
+}
+
+func (s *Scaler) targetUtilization() float64 {
+	if s.config.TargetUtilization > 0 {
+		return s.config.TargetUtilization
+	}
+	return defaultTargetUtilization
+}
+
+func (s *Scaler) deadband() float64 {
+	if s.config.ScalingDeadband > 0 {
+		return s.config.ScalingDeadband
+	}
+	return defaultScalingDeadband
+}
+
+// evaluateScalingThreshold is Config.ScalingMode == ScalingModeThreshold's
+// controller, kept for deployments that already tuned ScaleUpThreshold and
+// ScaleDownThreshold: it grants the next worker to the highest-priority
+// queue that has backlog and still has room under its weighted fair share,
+// or shrinks the lowest-priority queue that has gone quiet, at most one
+// worker change per tick.
+func (s *Scaler) evaluateScalingThreshold() {
+	if pool := s.pickScaleUpCandidate(); pool != nil {
+		if err := s.consumer.addWorker(pool.config.Name); err != nil {
+			s.logger.Error("failed to scale up queue", zap.String("queue", pool.config.Name), zap.Error(err))
+		}
+		s.ResetCooldown()
 		return
 	}
 
-	if s.shouldScaleDown(queueDepth, processingTime, workerUtilization) {
-		if time.Since(s.lastScaleEvent) > s.config.CooldownPeriod {
-			err := s.consumer.removeWorker()
-			if err != nil {
-				log.Printf("Failed to scale down: %v", err)
+	lastScaleEvent := time.Unix(0, s.lastScaleEvent.Load())
+	if time.Since(lastScaleEvent) > s.config.CooldownPeriod {
+		if pool := s.pickScaleDownCandidate(); pool != nil {
+			if err := s.consumer.removeWorker(pool.config.Name); err != nil {
+				s.logger.Error("failed to scale down queue", zap.String("queue", pool.config.Name), zap.Error(err))
 			}
-			s.lastScaleEvent = time.Now()
+			s.ResetCooldown()
+		}
+	}
+}
+
+// pickScaleUpCandidate returns the highest-priority queue that needs more
+// workers and still has room under both its own MaxWorkers and its
+// weighted fair share of Config.MaxTotalWorkers, or nil if none qualifies.
+func (s *Scaler) pickScaleUpCandidate() *QueuePool {
+	var best *QueuePool
+	for _, pool := range s.consumer.pools {
+		if pool.workerCount() >= pool.config.MaxWorkers {
+			continue
+		}
+		if pool.workerCount() >= s.fairShare(pool) {
+			continue
+		}
+		if !s.queueNeedsScaleUp(pool) {
+			continue
+		}
+		if best == nil || pool.config.Priority > best.config.Priority {
+			best = pool
+		}
+	}
+	return best
+}
+
+// pickScaleDownCandidate returns the lowest-priority queue that has gone
+// quiet and still has workers to spare above its MinWorkers floor.
+func (s *Scaler) pickScaleDownCandidate() *QueuePool {
+	var best *QueuePool
+	for _, pool := range s.consumer.pools {
+		if pool.workerCount() <= pool.config.MinWorkers {
+			continue
+		}
+		if !s.queueNeedsScaleDown(pool) {
+			continue
 		}
+		if best == nil || pool.config.Priority < best.config.Priority {
+			best = pool
+		}
+	}
+	return best
+}
+
+// fairShare returns the number of workers pool is entitled to out of the
+// shared Config.MaxTotalWorkers budget, proportional to its priority weight
+// (priority / sum of all priorities) and clamped to its own
+// MinWorkers/MaxWorkers. With no budget configured, it returns the queue's
+// own MaxWorkers so it can scale independently.
+func (s *Scaler) fairShare(pool *QueuePool) int {
+	if s.config.MaxTotalWorkers <= 0 {
+		return pool.config.MaxWorkers
 	}
+
+	totalPriority := 0
+	for _, p := range s.consumer.pools {
+		totalPriority += p.config.Priority
+	}
+	if totalPriority == 0 {
+		return pool.config.MaxWorkers
+	}
+
+	share := s.config.MaxTotalWorkers * pool.config.Priority / totalPriority
+	if share < pool.config.MinWorkers {
+		share = pool.config.MinWorkers
+	}
+	if share > pool.config.MaxWorkers {
+		share = pool.config.MaxWorkers
+	}
+	return share
 }
 
-func (s *Scaler) shouldScaleUp(queueDepth, processingTime, utilization float64) bool {
-	return queueDepth > s.config.ScaleUpThreshold ||
+func (s *Scaler) queueNeedsScaleUp(pool *QueuePool) bool {
+	qc := pool.config
+	queueDepth := s.collector.GetMetric(MetricQueueDepth, qc.Name)
+	processingTime := s.collector.GetMetricAverage(MetricProcessingTime, qc.Name, 1*time.Minute)
+	utilization := s.collector.GetMetricAverage(MetricWorkerUtilization, qc.Name, 1*time.Minute)
+
+	return queueDepth > qc.ScaleUpThreshold ||
 		utilization > 75.0 ||
 		processingTime > s.config.TargetProcessingTime.Seconds()
 }
 
-func (s *Scaler) shouldScaleDown(queueDepth, processingTime, utilization float64) bool {
-	return queueDepth < s.config.ScaleDownThreshold &&
+func (s *Scaler) queueNeedsScaleDown(pool *QueuePool) bool {
+	qc := pool.config
+	queueDepth := s.collector.GetMetric(MetricQueueDepth, qc.Name)
+	processingTime := s.collector.GetMetricAverage(MetricProcessingTime, qc.Name, 1*time.Minute)
+	utilization := s.collector.GetMetricAverage(MetricWorkerUtilization, qc.Name, 1*time.Minute)
+
+	return queueDepth < qc.ScaleDownThreshold &&
 		utilization < 40.0 &&
 		processingTime < s.config.TargetProcessingTime.Seconds()
 }
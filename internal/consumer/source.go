@@ -0,0 +1,61 @@
+package consumer
+
+import (
+	"context"
+	"time"
+)
+
+// Message is a single unit of work read from a Source, broker-agnostic by
+// design: Handler, Router, and Worker only ever see this shape, never the
+// SQS/Kafka/in-memory type underneath it.
+type Message struct {
+	// ID identifies the message for logging, crash reports, and metrics.
+	ID string
+	// Body is the raw message payload.
+	Body string
+	// Attributes carries the message's broker-native attributes (SQS message
+	// attributes, Kafka headers, ...) as plain strings. Router reads
+	// EventTypeAttribute from here, and Worker reads/writes ErrorTypeAttribute
+	// and AttemptCountAttribute here across retries; a Source must preserve
+	// whatever it's given across a Nack (sqssource does this by resending the
+	// message, since SQS has no API to attach attributes to one already in
+	// flight).
+	Attributes map[string]string
+	// Receipt is an opaque token the Source that produced this Message needs
+	// to Ack or Nack it later - an SQS receipt handle, a Kafka
+	// partition/offset pair, a memsource index. Callers should treat it as
+	// opaque and pass it back unmodified.
+	Receipt string
+}
+
+// SourceStats summarizes a Source's current backlog, the shape
+// Consumer.pollQueueDepth and the scaler's queue-depth threshold checks
+// expect regardless of which broker is behind the Source: roughly SQS's
+// ApproximateNumberOfMessages and ApproximateNumberOfMessagesNotVisible
+// attributes.
+type SourceStats struct {
+	ApproximateNumberOfMessages           int
+	ApproximateNumberOfMessagesNotVisible int
+}
+
+// Source is the broker-agnostic interface Consumer and Worker poll and
+// acknowledge messages through, so the worker pool, scaler, and metrics
+// machinery built around it work the same whether a queue is backed by SQS,
+// Kafka, or an in-memory stub in tests. sqssource, kafkasource, and memsource
+// are the bundled implementations; main.go wires whichever one each
+// QueueConfig needs.
+type Source interface {
+	// Receive polls for up to max new messages, blocking according to the
+	// implementation's own poll/backoff behavior (e.g. SQS long polling).
+	Receive(ctx context.Context, max int32) ([]Message, error)
+	// Ack marks msg as successfully processed so it is not redelivered.
+	Ack(ctx context.Context, msg Message) error
+	// Nack marks msg as failed, to be redelivered no sooner than
+	// visibilityExtension from now. Implementations that can't honor a delay
+	// redeliver as soon as they're able to instead.
+	Nack(ctx context.Context, msg Message, visibilityExtension time.Duration) error
+	// Stats returns the Source's last-known backlog size. Implementations
+	// that need a network call to answer this typically cache it via a
+	// background refresh rather than blocking here.
+	Stats() SourceStats
+}
@@ -0,0 +1,52 @@
+// Package sqsdlq implements consumer.DLQClient over Amazon SQS: it sends a
+// message's body and attributes straight to a dead-letter queue via
+// SendMessage, for consumer.FailurePolicyExplicitDLQ.
+package sqsdlq
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+
+	"sqs-fargate-consumer/internal/consumer"
+)
+
+// Client implements consumer.DLQClient over a single SQS dead-letter queue.
+type Client struct {
+	sqsClient *sqs.Client
+	dlqURL    string
+}
+
+// New creates a Client that sends messages to dlqURL.
+func New(sqsClient *sqs.Client, dlqURL string) *Client {
+	return &Client{sqsClient: sqsClient, dlqURL: dlqURL}
+}
+
+// Send copies msg's body and attributes onto the dead-letter queue. The
+// caller (consumer.Worker) is responsible for acking msg off its source
+// queue once Send succeeds.
+func (c *Client) Send(ctx context.Context, msg consumer.Message) error {
+	_, err := c.sqsClient.SendMessage(ctx, &sqs.SendMessageInput{
+		QueueUrl:          &c.dlqURL,
+		MessageBody:       aws.String(msg.Body),
+		MessageAttributes: toMessageAttributes(msg.Attributes),
+	})
+	if err != nil {
+		return fmt.Errorf("sqsdlq: send: %w", err)
+	}
+	return nil
+}
+
+func toMessageAttributes(attrs map[string]string) map[string]types.MessageAttributeValue {
+	out := make(map[string]types.MessageAttributeValue, len(attrs))
+	for k, v := range attrs {
+		out[k] = types.MessageAttributeValue{
+			DataType:    aws.String("String"),
+			StringValue: aws.String(v),
+		}
+	}
+	return out
+}
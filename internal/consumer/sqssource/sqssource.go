@@ -0,0 +1,286 @@
+// Package sqssource implements consumer.Source over Amazon SQS: the same
+// receive/delete/change-visibility calls consumer.Worker made directly
+// before the Source abstraction existed.
+package sqssource
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+
+	"sqs-fargate-consumer/internal/consumer"
+)
+
+// defaultVisibilityTimeout, defaultWaitTimeSeconds, and
+// defaultStatsPollInterval are used when Config leaves the corresponding
+// field zero. defaultVisibilityTimeout matches SQS's own default;
+// defaultWaitTimeSeconds is SQS's maximum long-poll wait.
+const (
+	defaultVisibilityTimeout = 30 * time.Second
+	defaultWaitTimeSeconds   = 20
+	defaultStatsPollInterval = 10 * time.Second
+
+	// maxDelaySeconds is SQS's own limit on SendMessage's DelaySeconds.
+	maxDelaySeconds = 15 * time.Minute
+)
+
+// Config configures a Source.
+type Config struct {
+	// URL is the SQS queue this Source polls.
+	URL string
+	// VisibilityTimeout bounds how long SQS hides a received message from
+	// other workers before it becomes visible again. Defaults to 30s when
+	// zero.
+	VisibilityTimeout time.Duration
+	// WaitTimeSeconds controls long-poll wait time, 0-20 per the SQS API.
+	// Defaults to 20 when zero.
+	WaitTimeSeconds int32
+	// StatsPollInterval controls how often Stats' cached backlog is
+	// refreshed via GetQueueAttributes. Defaults to 10s when zero.
+	StatsPollInterval time.Duration
+}
+
+func (c *Config) setDefaults() {
+	if c.VisibilityTimeout <= 0 {
+		c.VisibilityTimeout = defaultVisibilityTimeout
+	}
+	if c.WaitTimeSeconds <= 0 {
+		c.WaitTimeSeconds = defaultWaitTimeSeconds
+	}
+	if c.StatsPollInterval <= 0 {
+		c.StatsPollInterval = defaultStatsPollInterval
+	}
+}
+
+// Source polls a single SQS queue. It implements consumer.Source.
+type Source struct {
+	client *sqs.Client
+	cfg    Config
+	stats  atomic.Value // consumer.SourceStats
+}
+
+// New creates a Source over client polling cfg.URL. Start Run in its own
+// goroutine alongside Consumer.Start so Stats has something to report.
+func New(client *sqs.Client, cfg Config) *Source {
+	cfg.setDefaults()
+	s := &Source{client: client, cfg: cfg}
+	s.stats.Store(consumer.SourceStats{})
+	return s
+}
+
+// QueueURL returns the SQS queue this Source polls, letting
+// ChangeVisibilityOnSlow reach the same queue without needing its own
+// configuration.
+func (s *Source) QueueURL() string {
+	return s.cfg.URL
+}
+
+// ChangeVisibilityOnSlow extends an in-flight message's visibility timeout
+// while next is still running, so a handler that takes close to or longer
+// than visibilityTimeout doesn't have the message redelivered to another
+// worker out from under it. It checks in every visibilityTimeout-threshold
+// and extends by extendBy each time, until next returns or ctx is done. It
+// requires the queue URL consumer.Worker attaches to ctx via
+// consumer.WithQueueURL, and is a no-op if ctx doesn't carry one - so it's
+// harmless to register even for a handler shared across queues backed by
+// other Sources.
+func ChangeVisibilityOnSlow(client *sqs.Client, visibilityTimeout, threshold, extendBy time.Duration) consumer.Middleware {
+	interval := visibilityTimeout - threshold
+	if interval <= 0 {
+		interval = visibilityTimeout
+	}
+
+	return func(next consumer.Handler) consumer.Handler {
+		return consumer.HandlerFunc(func(ctx context.Context, msg consumer.Message) error {
+			queueURL, ok := consumer.QueueURLFromContext(ctx)
+			if !ok {
+				return next.Handle(ctx, msg)
+			}
+
+			done := make(chan error, 1)
+			go func() { done <- next.Handle(ctx, msg) }()
+
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case err := <-done:
+					return err
+				case <-ticker.C:
+					if _, err := client.ChangeMessageVisibility(ctx, &sqs.ChangeMessageVisibilityInput{
+						QueueUrl:          aws.String(queueURL),
+						ReceiptHandle:     aws.String(msg.Receipt),
+						VisibilityTimeout: int32(extendBy.Seconds()),
+					}); err != nil {
+						return fmt.Errorf("sqssource: extend visibility timeout: %w", err)
+					}
+				}
+			}
+		})
+	}
+}
+
+// Run refreshes Stats on cfg.StatsPollInterval until ctx is done.
+func (s *Source) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.StatsPollInterval)
+	defer ticker.Stop()
+
+	s.refreshStats(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.refreshStats(ctx)
+		}
+	}
+}
+
+func (s *Source) Receive(ctx context.Context, max int32) ([]consumer.Message, error) {
+	output, err := s.client.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+		QueueUrl:            &s.cfg.URL,
+		MaxNumberOfMessages: max,
+		WaitTimeSeconds:     s.cfg.WaitTimeSeconds,
+		VisibilityTimeout:   int32(s.cfg.VisibilityTimeout.Seconds()),
+		// SQS omits all custom message attributes unless explicitly
+		// requested; without this, Router dispatch (event_type) and
+		// extractTraceContext's traceparent attribute would always be empty,
+		// matching consumer/dlq's own ReceiveMessage call.
+		MessageAttributeNames: []string{"All"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("sqssource: receive: %w", err)
+	}
+
+	messages := make([]consumer.Message, len(output.Messages))
+	for i, m := range output.Messages {
+		messages[i] = toMessage(m)
+	}
+	return messages, nil
+}
+
+// Ack deletes msg from the queue.
+func (s *Source) Ack(ctx context.Context, msg consumer.Message) error {
+	_, err := s.client.DeleteMessage(ctx, &sqs.DeleteMessageInput{
+		QueueUrl:      &s.cfg.URL,
+		ReceiptHandle: aws.String(msg.Receipt),
+	})
+	if err != nil {
+		return fmt.Errorf("sqssource: ack: %w", err)
+	}
+	return nil
+}
+
+// Nack marks msg as failed by resending it to the same queue with
+// visibilityExtension as its delay, then deleting the original. SQS has no
+// API to attach message attributes to a message already in flight - only
+// ChangeMessageVisibility, which can't carry them - so a plain visibility
+// reset would silently drop msg.Attributes (in particular
+// consumer.ErrorTypeAttribute/AttemptCountAttribute, which
+// consumer/dlq.classify needs to see once a message reaches the
+// dead-letter queue via the queue's own maxReceiveCount redrive, the common
+// case under FailurePolicyBackoff). The trade-off is that this resets SQS's
+// own ApproximateReceiveCount on every nack, same as SendMessage always
+// does.
+func (s *Source) Nack(ctx context.Context, msg consumer.Message, visibilityExtension time.Duration) error {
+	_, err := s.client.SendMessage(ctx, &sqs.SendMessageInput{
+		QueueUrl:          &s.cfg.URL,
+		MessageBody:       aws.String(msg.Body),
+		MessageAttributes: toMessageAttributes(msg.Attributes),
+		DelaySeconds:      delaySeconds(visibilityExtension),
+	})
+	if err != nil {
+		return fmt.Errorf("sqssource: nack: resend: %w", err)
+	}
+
+	if err := s.Ack(ctx, msg); err != nil {
+		return fmt.Errorf("sqssource: nack: delete original: %w", err)
+	}
+	return nil
+}
+
+// toMessageAttributes converts a consumer.Message's string attributes into
+// the shape SendMessage expects.
+func toMessageAttributes(attrs map[string]string) map[string]types.MessageAttributeValue {
+	out := make(map[string]types.MessageAttributeValue, len(attrs))
+	for k, v := range attrs {
+		out[k] = types.MessageAttributeValue{
+			DataType:    aws.String("String"),
+			StringValue: aws.String(v),
+		}
+	}
+	return out
+}
+
+// Stats returns the last backlog snapshot Run's background poll loop
+// fetched.
+func (s *Source) Stats() consumer.SourceStats {
+	return s.stats.Load().(consumer.SourceStats)
+}
+
+func (s *Source) refreshStats(ctx context.Context) {
+	output, err := s.client.GetQueueAttributes(ctx, &sqs.GetQueueAttributesInput{
+		QueueUrl: &s.cfg.URL,
+		AttributeNames: []types.QueueAttributeName{
+			types.QueueAttributeNameApproximateNumberOfMessages,
+			types.QueueAttributeNameApproximateNumberOfMessagesNotVisible,
+		},
+	})
+	if err != nil {
+		log.Printf("sqssource: refresh stats for %s: %v", s.cfg.URL, err)
+		return
+	}
+
+	var stats consumer.SourceStats
+	if v, ok := output.Attributes[string(types.QueueAttributeNameApproximateNumberOfMessages)]; ok {
+		if n, err := strconv.Atoi(v); err == nil {
+			stats.ApproximateNumberOfMessages = n
+		}
+	}
+	if v, ok := output.Attributes[string(types.QueueAttributeNameApproximateNumberOfMessagesNotVisible)]; ok {
+		if n, err := strconv.Atoi(v); err == nil {
+			stats.ApproximateNumberOfMessagesNotVisible = n
+		}
+	}
+	s.stats.Store(stats)
+}
+
+// toMessage converts an SQS message, carrying forward whatever custom
+// message attributes it has - including consumer.AttemptCountAttribute and
+// consumer.ErrorTypeAttribute, which Nack's resend is what keeps those
+// populated across redeliveries.
+func toMessage(m types.Message) consumer.Message {
+	attrs := make(map[string]string, len(m.MessageAttributes))
+	for k, v := range m.MessageAttributes {
+		if v.StringValue != nil {
+			attrs[k] = *v.StringValue
+		}
+	}
+
+	return consumer.Message{
+		ID:         aws.ToString(m.MessageId),
+		Body:       aws.ToString(m.Body),
+		Attributes: attrs,
+		Receipt:    aws.ToString(m.ReceiptHandle),
+	}
+}
+
+// delaySeconds clamps d to SQS's 0-900 second (15-minute) DelaySeconds
+// range.
+func delaySeconds(d time.Duration) int32 {
+	if d <= 0 {
+		return 0
+	}
+	if d > maxDelaySeconds {
+		d = maxDelaySeconds
+	}
+	return int32(d.Seconds())
+}
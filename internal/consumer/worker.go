@@ -3,34 +3,73 @@ package consumer
 import (
 	"context"
 	"fmt"
-	"log"
+	"strconv"
 	"sync/atomic"
 	"time"
 
-	"github.com/aws/aws-sdk-go-v2/service/sqs"
-	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// Message attributes a worker attaches to a message it reports as failed.
+// AttemptCountAttribute is read back on the next delivery to compute the next
+// backoff; both it and ErrorTypeAttribute must survive a Nack (sqssource
+// does this by resending the message) so consumer/dlq can read them back
+// once a message lands in the dead-letter queue - whether it arrived there
+// via consumer.DLQClient.Send's explicit move or the queue's own
+// maxReceiveCount redrive policy - to decide how to handle it.
+const (
+	ErrorTypeAttribute    = "X-Error-Type"
+	AttemptCountAttribute = "X-Attempt-Count"
 )
 
 type Worker struct {
 	id               string
-	client           *sqs.Client
-	queueURL         string
+	source           Source
+	queueName        string // stable label used for metrics
 	metricsCollector *MetricsCollector
+	handler          Handler
+	maxRetries       int // caps the exponential backoff applied to nacked messages
+	policy           FailurePolicy
+	dlqClient        DLQClient // nil unless policy.Mode is FailurePolicyExplicitDLQ
+	logger           *zap.Logger
+	tracer           trace.Tracer
 	done             chan bool
-	processingTime   time.Duration
-	isProcessing     atomic.Bool  // Track if worker is currently processing
-	processedCount   atomic.Int64 // Count of messages processed in current window
-	lastWindowTime   atomic.Int64 // Last time window for utilization calculation
+	batchSize        int32
+	isProcessing     atomic.Bool             // Track if worker is currently processing
+	processedCount   atomic.Int64            // Count of messages processed in current window
+	lastWindowTime   atomic.Int64            // Last time window for utilization calculation
+	currentMessage   atomic.Pointer[Message] // Message in flight, if any; read by Consumer.runWorker for crash reports
+	lastMessageAt    atomic.Int64            // Unix nano of when the current/last message started processing; read by AdminServer
+	draining         atomic.Bool             // Set by Drain; stops polling for new messages without interrupting one in flight
 }
 
-func NewWorker(id string, client *sqs.Client, queueURL string, collector *MetricsCollector) *Worker {
+// defaultBatchSize is used when a QueueConfig leaves BatchSize zero.
+const defaultBatchSize = 10
+
+func NewWorker(id string, source Source, queueName string, collector *MetricsCollector, handler Handler, maxRetries int, qc QueueConfig, policy FailurePolicy, dlqClient DLQClient, logger *zap.Logger) *Worker {
+	batchSize := qc.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+
 	w := &Worker{
 		id:               id,
-		client:           client,
-		queueURL:         queueURL,
+		source:           source,
+		queueName:        queueName,
 		metricsCollector: collector,
+		handler:          handler,
+		maxRetries:       maxRetries,
+		policy:           policy,
+		dlqClient:        dlqClient,
+		logger:           logger,
+		tracer:           otel.Tracer("sqs-fargate-consumer/internal/consumer"),
 		done:             make(chan bool),
-		processingTime:   30 * time.Second,
+		batchSize:        batchSize,
 	}
 
 	// Initialize atomic values
@@ -50,32 +89,67 @@ func (w *Worker) Start(ctx context.Context) {
 		case <-w.done:
 			return
 		default:
+			if w.draining.Load() {
+				time.Sleep(1 * time.Second)
+				continue
+			}
+
 			messages, err := w.pollMessages(ctx)
 			if err != nil {
-				w.metricsCollector.RecordError("poll_error")
+				w.metricsCollector.RecordError("poll_error", map[string]string{"queue": w.queueName, "worker_id": w.id})
 				time.Sleep(1 * time.Second) // Backoff on error
 				continue
 			}
 
 			for _, msg := range messages {
-				startTime := time.Now()
-				w.isProcessing.Store(true)
-
-				if err := w.processMessage(ctx, msg); err != nil {
-					w.handleError(ctx, msg, err)
-				} else {
-					w.deleteMessage(ctx, msg)
-					w.processedCount.Add(1)
-				}
-
-				w.isProcessing.Store(false)
-				processingDuration := time.Since(startTime)
-				w.metricsCollector.RecordProcessingTime(processingDuration)
+				w.handleMessage(ctx, msg)
 			}
 		}
 	}
 }
 
+// handleMessage runs the "per-message handler" stage for msg, wrapped in a
+// span that continues the trace an upstream producer started: if msg
+// carries a traceparent attribute, the span is a child of that trace rather
+// than starting a new one.
+func (w *Worker) handleMessage(ctx context.Context, msg Message) {
+	startTime := time.Now()
+	w.isProcessing.Store(true)
+	w.currentMessage.Store(&msg)
+	w.lastMessageAt.Store(startTime.UnixNano())
+
+	msgCtx := w.extractTraceContext(ctx, msg)
+	msgCtx, span := w.tracer.Start(msgCtx, "sqs.handle", trace.WithAttributes(
+		attribute.String("message_id", msg.ID),
+		attribute.String("queue", w.queueName),
+	))
+
+	if err := w.processMessage(msgCtx, msg); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		w.handleError(msgCtx, msg, err)
+	} else {
+		w.ackMessage(msgCtx, msg)
+		w.processedCount.Add(1)
+	}
+	span.End()
+
+	w.currentMessage.Store(nil)
+	w.isProcessing.Store(false)
+	w.metricsCollector.RecordProcessingTime(w.queueName, time.Since(startTime))
+}
+
+// extractTraceContext continues the trace an upstream producer started, if
+// msg carries a traceparent attribute (or any other attribute the
+// configured propagator recognizes), so this message's spans and the
+// producer's show up correlated in the same trace.
+func (w *Worker) extractTraceContext(ctx context.Context, msg Message) context.Context {
+	if len(msg.Attributes) == 0 {
+		return ctx
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(msg.Attributes))
+}
+
 func (w *Worker) trackUtilization() {
 	ticker := time.NewTicker(10 * time.Second)
 	defer ticker.Stop()
@@ -99,7 +173,7 @@ func (w *Worker) trackUtilization() {
 					utilization = 100
 				}
 
-				w.metricsCollector.RecordWorkerUtilization(utilization)
+				w.metricsCollector.RecordWorkerUtilization(w.queueName, utilization)
 			}
 		}
 	}
@@ -109,44 +183,182 @@ func (w *Worker) Stop() {
 	close(w.done)
 }
 
-func (w *Worker) pollMessages(ctx context.Context) ([]types.Message, error) {
-	output, err := w.client.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
-		QueueUrl:            &w.queueURL,
-		MaxNumberOfMessages: 10,
-		WaitTimeSeconds:     20, // Long polling
-		VisibilityTimeout:   int32(w.processingTime.Seconds()),
-	})
+// Drain stops the worker from polling for new messages; a message already
+// being processed is allowed to finish. Used by AdminServer's
+// POST /admin/drain for graceful Fargate SIGTERM handling.
+func (w *Worker) Drain() {
+	w.draining.Store(true)
+}
+
+// CurrentMessage returns the message this worker is currently processing, or
+// nil if it's idle. Consumer.runWorker reads this to attach message context
+// to a CrashReport when the worker's goroutine panics.
+func (w *Worker) CurrentMessage() *Message {
+	return w.currentMessage.Load()
+}
+
+// LastMessageAt returns when this worker started processing its current (or
+// most recent) message, or the zero time if it hasn't processed one yet.
+func (w *Worker) LastMessageAt() time.Time {
+	nanos := w.lastMessageAt.Load()
+	if nanos == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nanos)
+}
+
+// pollMessages runs the "poll" and "receive batch" stages: source.Receive
+// blocks according to its own poll/backoff behavior (e.g. SQS long
+// polling), then the batch that comes back is recorded as its own span so
+// the two stages are visible separately in a trace even though a single
+// broker call produces both.
+func (w *Worker) pollMessages(ctx context.Context) ([]Message, error) {
+	pollCtx, pollSpan := w.tracer.Start(ctx, "sqs.poll")
+	messages, err := w.source.Receive(pollCtx, w.batchSize)
+	pollSpan.End()
 	if err != nil {
 		return nil, fmt.Errorf("failed to poll messages: %w", err)
 	}
-	return output.Messages, nil
+
+	_, batchSpan := w.tracer.Start(ctx, "sqs.receive_batch", trace.WithAttributes(
+		attribute.Int("message_count", len(messages)),
+	))
+	batchSpan.End()
+
+	return messages, nil
 }
 
-func (w *Worker) processMessage(ctx context.Context, msg types.Message) error {
-	log.Printf("Processing message %s", *msg.MessageId)
-	time.Sleep(5 * time.Second) // Simulate processing time
-	return nil
+// processMessage invokes the handler chain, attaching the originating
+// queue's address to ctx first when the Source has one (see
+// queueURLSource), so SQS-aware middleware like
+// sqssource.ChangeVisibilityOnSlow can reach it.
+func (w *Worker) processMessage(ctx context.Context, msg Message) error {
+	if qu, ok := w.source.(queueURLSource); ok {
+		ctx = WithQueueURL(ctx, qu.QueueURL())
+	}
+	return w.handler.Handle(ctx, msg)
 }
 
-func (w *Worker) deleteMessage(ctx context.Context, msg types.Message) error {
-	_, err := w.client.DeleteMessage(ctx, &sqs.DeleteMessageInput{
-		QueueUrl:      &w.queueURL,
-		ReceiptHandle: msg.ReceiptHandle,
-	})
-	return err
+// ackMessage runs the "delete" stage for a successfully handled message.
+func (w *Worker) ackMessage(ctx context.Context, msg Message) {
+	w.metricsCollector.RecordReceiveCount(w.queueName, attemptCount(msg)+1)
+
+	ctx, span := w.tracer.Start(ctx, "sqs.delete")
+	defer span.End()
+
+	if err := w.source.Ack(ctx, msg); err != nil {
+		span.RecordError(err)
+		w.logger.Error("failed to ack message", zap.String("message_id", msg.ID), zap.String("queue", w.queueName), zap.Error(err))
+	}
 }
 
-func (w *Worker) handleError(ctx context.Context, msg types.Message, err error) {
-	log.Printf("Error processing message %s: %v", *msg.MessageId, err)
-	w.metricsCollector.RecordError("processing_error")
+// handleError decides what happens to a message whose handler returned err,
+// per w.policy (see FailurePolicyMode):
+//
+//   - FailurePolicyNatural leaves the message alone; its visibility timeout
+//     expires on its own and the broker redelivers it.
+//   - FailurePolicyBackoff (the default) nacks it with an exponential
+//     visibility-extension delay.
+//   - FailurePolicyExplicitDLQ does the same until attempt reaches
+//     maxReceives, then moves the message to w.dlqClient directly.
+func (w *Worker) handleError(ctx context.Context, msg Message, err error) {
+	attempt := attemptCount(msg) + 1
+	w.logger.Warn("error processing message", zap.String("message_id", msg.ID), zap.Int("attempt", attempt), zap.Error(err))
+	w.metricsCollector.RecordError("processing_error", map[string]string{"queue": w.queueName, "worker_id": w.id})
 
-	// Modify visibility timeout to retry later
-	_, changeErr := w.client.ChangeMessageVisibility(ctx, &sqs.ChangeMessageVisibilityInput{
-		QueueUrl:          &w.queueURL,
-		ReceiptHandle:     msg.ReceiptHandle,
-		VisibilityTimeout: 30, // Reset to 30 seconds
-	})
-	if changeErr != nil {
-		log.Printf("Error changing message visibility: %v", changeErr)
+	msg.Attributes = attributesWithError(msg.Attributes, attempt)
+
+	if w.policy.Mode == FailurePolicyExplicitDLQ && w.dlqClient != nil && attempt >= w.maxReceives() {
+		w.moveToDLQ(ctx, msg, attempt)
+		return
+	}
+
+	if w.policy.Mode == FailurePolicyNatural {
+		return
+	}
+
+	if nackErr := w.source.Nack(ctx, msg, backoffDelay(attempt, w.maxRetries)); nackErr != nil {
+		w.logger.Error("failed to nack message", zap.String("message_id", msg.ID), zap.String("queue", w.queueName), zap.Error(nackErr))
+	}
+}
+
+// maxReceives is the attempt count at which FailurePolicyExplicitDLQ moves a
+// message to the dead-letter queue itself, defaulting to w.maxRetries when
+// policy.MaxReceives is left zero.
+func (w *Worker) maxReceives() int {
+	if w.policy.MaxReceives > 0 {
+		return w.policy.MaxReceives
+	}
+	return w.maxRetries
+}
+
+// moveToDLQ sends msg to w.dlqClient and, on success, acks it off the
+// source queue so it isn't also redelivered there. If the send fails, it
+// falls back to the normal backoff nack so the message isn't lost.
+func (w *Worker) moveToDLQ(ctx context.Context, msg Message, attempt int) {
+	if err := w.dlqClient.Send(ctx, msg); err != nil {
+		w.logger.Error("failed to move message to dlq, falling back to backoff", zap.String("message_id", msg.ID), zap.String("queue", w.queueName), zap.Error(err))
+		if nackErr := w.source.Nack(ctx, msg, backoffDelay(attempt, w.maxRetries)); nackErr != nil {
+			w.logger.Error("failed to nack message", zap.String("message_id", msg.ID), zap.String("queue", w.queueName), zap.Error(nackErr))
+		}
+		return
+	}
+
+	w.metricsCollector.RecordReceiveCount(w.queueName, attempt)
+	w.metricsCollector.RecordMessageDLQd(w.queueName)
+
+	if err := w.source.Ack(ctx, msg); err != nil {
+		w.logger.Error("failed to remove message from queue after dlq move", zap.String("message_id", msg.ID), zap.String("queue", w.queueName), zap.Error(err))
+	}
+}
+
+// attributesWithError returns a copy of attrs with the error-type and
+// attempt-count attributes set to record this failure, carrying that
+// bookkeeping forward to wherever the message ends up next.
+func attributesWithError(attrs map[string]string, attempt int) map[string]string {
+	out := make(map[string]string, len(attrs)+2)
+	for k, v := range attrs {
+		out[k] = v
+	}
+	out[ErrorTypeAttribute] = "processing_error"
+	out[AttemptCountAttribute] = strconv.Itoa(attempt)
+	return out
+}
+
+// attemptCount reads back the attempt counter a previous nack attached, or 0
+// if this is the message's first failure.
+func attemptCount(msg Message) int {
+	v, ok := msg.Attributes[AttemptCountAttribute]
+	if !ok {
+		return 0
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+const (
+	backoffBase = 30 * time.Second
+	backoffCap  = 15 * time.Minute
+)
+
+// backoffDelay computes an exponential visibility-extension delay for a
+// nacked message, capped by backoffCap. maxRetries bounds how far the
+// exponent can climb so a message nearing its redrive threshold doesn't get
+// scheduled further out than it has attempts left to matter.
+func backoffDelay(attempt, maxRetries int) time.Duration {
+	if maxRetries > 0 && attempt > maxRetries {
+		attempt = maxRetries
+	}
+
+	delay := backoffBase
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= backoffCap {
+			return backoffCap
+		}
 	}
+	return delay
 }
@@ -0,0 +1,93 @@
+// Package telemetry wires up OpenTelemetry tracing and metrics export over
+// OTLP, so deployments that run a Prometheus/Grafana (or any other
+// OTLP-speaking) stack can get consumer.Worker's spans and
+// consumer.MetricsCollector's metrics without needing CloudWatch at all. It
+// is a no-op when no OTLP endpoint is configured: otel.Tracer and otel.Meter
+// calls made before Init - or after it runs with an empty endpoint - fall
+// back to OpenTelemetry's own no-op implementations.
+package telemetry
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Config configures the OTLP/gRPC exporters Init builds.
+type Config struct {
+	// ServiceName identifies this process in exported traces and metrics.
+	ServiceName string
+	// OTLPEndpoint is the collector address (host:port) spans and metrics
+	// are exported to. Leave empty to skip OpenTelemetry entirely.
+	OTLPEndpoint string
+	// Insecure disables TLS on the OTLP gRPC connection, for talking to a
+	// collector sidecar over a loopback or private network.
+	Insecure bool
+}
+
+// Shutdown flushes and closes every exporter Init started.
+type Shutdown func(context.Context) error
+
+// Init sets the global TracerProvider, MeterProvider, and trace-context
+// propagator from cfg. consumer.Worker's spans and MetricsCollector's otel
+// instruments are recorded through the global providers, so this must run
+// before either is used to take effect. If cfg.OTLPEndpoint is empty, Init
+// does nothing and returns a no-op Shutdown.
+func Init(ctx context.Context, cfg Config) (Shutdown, error) {
+	noop := func(context.Context) error { return nil }
+	if cfg.OTLPEndpoint == "" {
+		return noop, nil
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		attribute.String("service.name", cfg.ServiceName),
+	))
+	if err != nil {
+		return noop, fmt.Errorf("telemetry: build resource: %w", err)
+	}
+
+	traceOpts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(cfg.OTLPEndpoint)}
+	metricOpts := []otlpmetricgrpc.Option{otlpmetricgrpc.WithEndpoint(cfg.OTLPEndpoint)}
+	if cfg.Insecure {
+		traceOpts = append(traceOpts, otlptracegrpc.WithInsecure())
+		metricOpts = append(metricOpts, otlpmetricgrpc.WithInsecure())
+	}
+
+	traceExporter, err := otlptracegrpc.New(ctx, traceOpts...)
+	if err != nil {
+		return noop, fmt.Errorf("telemetry: build trace exporter: %w", err)
+	}
+
+	metricExporter, err := otlpmetricgrpc.New(ctx, metricOpts...)
+	if err != nil {
+		return noop, fmt.Errorf("telemetry: build metric exporter: %w", err)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(traceExporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	mp := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metricExporter)),
+		sdkmetric.WithResource(res),
+	)
+	otel.SetMeterProvider(mp)
+
+	return func(shutdownCtx context.Context) error {
+		if err := tp.Shutdown(shutdownCtx); err != nil {
+			return err
+		}
+		return mp.Shutdown(shutdownCtx)
+	}, nil
+}
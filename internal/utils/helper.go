@@ -3,10 +3,13 @@ package utils
 import (
 	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"net/http"
 
 	gosecrets "github.com/gdcorp-domains/fulfillment-gosecrets"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 // RetrieveCert retriees cert and key from secret manager
@@ -39,7 +42,37 @@ func RetrieveCert(certName string, keyName string) (*tls.Certificate, error) {
 	return &cert, nil
 }
 
-func StartHTTPSServer() {
+// RetrieveCACert fetches a PEM-encoded CA bundle from the secret manager and
+// returns a pool suitable for verifying client certificates, for mTLS.
+func RetrieveCACert(caCertName string) (*x509.CertPool, error) {
+	secretRetriever := gosecrets.NewSecretRetriever()
+
+	caBytes, err := secretRetriever.Get(context.Background(), gosecrets.SecretConfig{
+		AWS: &gosecrets.AWSSecretConfig{
+			Name:   caCertName,
+			Region: "us-west-2",
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caBytes) {
+		return nil, fmt.Errorf("no certificates found in secret %q", caCertName)
+	}
+	return pool, nil
+}
+
+// StartHTTPSServer starts the TLS health/ready/metrics server. promRegistry
+// mounts a Prometheus scrape endpoint at /metrics; pass nil to skip it when
+// the prometheus metrics backend isn't enabled. extraRoutes, if non-nil,
+// mounts additional handlers (e.g. admin endpoints) by URL pattern.
+// adminCACertName, when non-empty, names the secret holding the CA bundle
+// used to verify client certificates: the whole server requires a valid
+// client cert (mTLS), protecting the admin endpoints in extraRoutes on top
+// of the X-Admin-Token check they apply themselves when a token is set.
+func StartHTTPSServer(promRegistry *prometheus.Registry, extraRoutes map[string]http.Handler, adminCACertName string) {
 	// Retrieve cert and key from secret manager
 	registrarSvcCert, err := RetrieveCert("registrar.dev.client.int.godaddy.com.crt", "registrar.dev.client.int.godaddy.com.key")
 	if err != nil {
@@ -47,14 +80,26 @@ func StartHTTPSServer() {
 		panic(err)
 	}
 
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{*registrarSvcCert},
+	}
+
+	if adminCACertName != "" {
+		caPool, err := RetrieveCACert(adminCACertName)
+		if err != nil {
+			fmt.Print("error retrieving admin mTLS CA cert")
+			panic(err)
+		}
+		tlsConfig.ClientCAs = caPool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
 	go func() {
 		fmt.Println("Creating https server")
 		s := &http.Server{
-			Addr:    ":443",
-			Handler: nil,
-			TLSConfig: &tls.Config{
-				Certificates: []tls.Certificate{*registrarSvcCert},
-			},
+			Addr:      ":443",
+			Handler:   nil,
+			TLSConfig: tlsConfig,
 		}
 
 		http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
@@ -65,6 +110,14 @@ func StartHTTPSServer() {
 			w.WriteHeader(http.StatusOK)
 		})
 
+		if promRegistry != nil {
+			http.Handle("/metrics", promhttp.HandlerFor(promRegistry, promhttp.HandlerOpts{}))
+		}
+
+		for pattern, handler := range extraRoutes {
+			http.Handle(pattern, handler)
+		}
+
 		fmt.Println("Https server is listening on 443 with TLS")
 
 		if err := s.ListenAndServeTLS("", ""); err != nil {